@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// daysPerWeek the length of a weekly availability pattern
+const daysPerWeek = 7
+
+// parseAvailability read the availability of the workers, comma separated,
+// each a fraction of the capacity or a weekly pattern of 7 fractions
+// separated by slashes from the first day on
+func parseAvailability(s string) ([][]float64, error) {
+	var workers [][]float64
+	for _, w := range strings.Split(s, ",") {
+		var pattern []float64
+		for _, f := range strings.Split(w, "/") {
+			v, err := strconv.ParseFloat(f, 64)
+			if err != nil {
+				return nil, err
+			}
+			pattern = append(pattern, v)
+		}
+		if len(pattern) != 1 && len(pattern) != daysPerWeek {
+			return nil, fmt.Errorf("availability %q not 1 or %d fractions",
+				w, daysPerWeek)
+		}
+		workers = append(workers, pattern)
+	}
+	return workers, nil
+}
+
+// availability return the sum of the availability fractions of the workers
+// on day, 1 for a single full-time worker without availabilities
+func (p *Params) availability(day int) float64 {
+	if len(p.Availability) == 0 {
+		return 1
+	}
+	sum := 0.0
+	for _, pattern := range p.Availability {
+		sum += pattern[day%len(pattern)]
+	}
+	return sum
+}
+
+// meanAvailability return the mean of the availability over a week
+func (p *Params) meanAvailability() float64 {
+	sum := 0.0
+	for d := 0; d < daysPerWeek; d++ {
+		sum += p.availability(d)
+	}
+	return sum / daysPerWeek
+}
+
+// workingDay reports whether any worker is available on day
+func (p *Params) workingDay(day int) bool {
+	return p.availability(day) > 0
+}
+
+// hasCalendar reports whether the availability has days without work,
+// then the days simulated are calendar days
+func (p *Params) hasCalendar() bool {
+	for d := 0; d < daysPerWeek; d++ {
+		if !p.workingDay(d) {
+			return true
+		}
+	}
+	return false
+}
+
+// workingLeadtime return the leadtime of the ticket in working days, the
+// days of its leadtime any worker is available
+func (p *Params) workingLeadtime(t *ticket) int {
+	n := 0
+	for d := t.startday; d < t.startday+t.leadtime; d++ {
+		if p.workingDay(d) {
+			n++
+		}
+	}
+	return n
+}
+
+// statsWorkingLeadTime return mean, standard deviation and their sum of the
+// leadtimes of the tickets in working days
+func (sim simulation) statsWorkingLeadTime() (float64, float64, float64) {
+	var w welford
+	for _, t := range sim.tickets {
+		if !t.cancelled {
+			w.add(float64(sim.params.workingLeadtime(t)))
+		}
+	}
+	mean, stdev := w.mean(), w.stdev()
+	return mean, stdev, mean + stdev
+}
@@ -0,0 +1,42 @@
+package main
+
+import "fmt"
+
+// cancel cancel the open tickets on day, each with probability p.CancelRate,
+// expedite tickets are never cancelled. A cancelled ticket stays in the
+// simulation, open until day, but its remaining work is gone, no
+// prerequisite waits for it and it is left out of the leadtime statistics.
+// The hours worked on it are wasted.
+func (sim *simulation) cancel(day int) {
+	for _, t := range sim.tickets {
+		if t.expedite || t.cancelled || t.current(day) == 0 ||
+			ticketDraw(sim.params.Seed, streamCancel, t, day) >=
+				sim.params.CancelRate {
+			continue
+		}
+		t.cancelled = true
+		for d := day; d < len(t.remaining); d++ {
+			t.remaining[d] = 0
+		}
+	}
+}
+
+// cancelReport return the count of cancelled tickets and the hours worked on
+// them including the ramp up, wasted, also as percentage of all hours worked
+func (sim simulation) cancelReport() string {
+	worked := 0
+	for _, h := range sim.worked {
+		worked += h
+	}
+	cancelled, wasted := 0, 0
+	for _, t := range sim.tickets {
+		if t.cancelled {
+			cancelled++
+			wasted += t.spent + t.ramped
+		}
+	}
+	return fmt.Sprintf("Cancelled tickets: %d, work wasted on them: %d h"+
+		" (%s of the hours worked)\n", cancelled, wasted,
+		orNA("%.1f%%", 100*float64(wasted)/float64(worked),
+			sim.params.Precision))
+}
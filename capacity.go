@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+)
+
+// capacityRuns the count of arrival sets each capacity is evaluated on
+const capacityRuns = 10
+
+// capacityStep the resolution in hours of the capacity search
+const capacityStep = 0.1
+
+// minCapacity search the minimal capacity in hours per day at which the
+// strategy with id meets the SLA, the mean SLA compliance over capacityRuns
+// arrival sets at least Params.SlaPercent. The arrival sets are seeded from
+// the seed of the parameters. The search bisects between a capacity failing
+// the SLA and one meeting it. The offered work per day is tried first and
+// doubled up to 16 times the offered work until the SLA is met, the last
+// capacity failing it is the lower bound. If the offered work meets the SLA
+// the lower bound is the overhead, no hours left for tickets. A compliance
+// without done tickets, NaN, fails the SLA. Return the capacity and the mean
+// compliance at it, false if the SLA is not met. If the context is cancelled
+// return the error.
+func minCapacity(ctx context.Context, p *Params, id string) (float64,
+	float64, bool, error) {
+	st, ok := findStrategy(id)
+	if !ok {
+		return 0, 0, false, fmt.Errorf("unknown strategy %s", id)
+	}
+	sets := make([][][]*ticket, capacityRuns)
+	for i := range sets {
+		pr := *p
+		pr.Seed = p.Seed + 100 + int64(i)
+		smp := newSampler(rand.New(rand.NewSource(pr.Seed)), &pr)
+		sets[i], _, _ = createArrivals(&pr, smp, io.Discard)
+	}
+	sz := p.Days * 3 / 2 // some more size avoid reallocation
+	compliance := func(capacity float64) (float64, error) {
+		pc := *p
+		pc.Capacity = capacity
+		sum := 0.0
+		for _, arrivals := range sets {
+			simset, err := simulationset{NewSimulation(st, &pc, sz)}.run(ctx,
+				arrivals)
+			if err = warnDrain("min-capacity", err); err != nil {
+				return 0, err
+			}
+			sum += simset[0].slaCompliance(p.SlaDays)
+		}
+		return sum / capacityRuns, nil
+	}
+	offered := p.MeanNewPerDay*p.MeanEffortNew + p.Overhead
+	lo, hi := p.Overhead, offered
+	c, err := compliance(hi)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	for !(c >= p.SlaPercent) {
+		if hi >= 16*offered {
+			return hi, c, false, nil
+		}
+		lo, hi = hi, 2*hi
+		if c, err = compliance(hi); err != nil {
+			return 0, 0, false, err
+		}
+	}
+	best := c
+	for hi-lo > capacityStep {
+		mid := (lo + hi) / 2
+		c, err := compliance(mid)
+		if err != nil {
+			return 0, 0, false, err
+		}
+		if c >= p.SlaPercent {
+			hi, best = mid, c
+		} else {
+			lo = mid
+		}
+	}
+	return hi, best, true, nil
+}
+
+// capacityReport create the report of the minimal capacity for the SLA
+func capacityReport(p *Params, name string, capacity, compliance float64,
+	met bool) string {
+	if !met {
+		frmt := withPrecision("SLA %.0f%% within %d days not met with %.1f"+
+			" hours/day under strategy %s, compliance: %.1f%%\n", p.Precision)
+		return fmt.Sprintf(frmt, p.SlaPercent, p.SlaDays, capacity, name,
+			compliance)
+	}
+	frmt := withPrecision("You need %.1f hours/day to meet the SLA %.0f%%"+
+		" within %d days under strategy %s, compliance: %.1f%%\n", p.Precision)
+	return fmt.Sprintf(frmt, capacity, p.SlaPercent, p.SlaDays, name,
+		compliance)
+}
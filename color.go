@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// the ANSI colors of the best and the worst metric
+const (
+	colorBest  = "\x1b[32m"
+	colorWorst = "\x1b[31m"
+	colorReset = "\x1b[0m"
+)
+
+// useColor color the best and worst metrics of the strategies in the text
+// output, set if the output is a terminal
+var useColor = false
+
+// isTerminal reports whether the file is a terminal
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// colorMetric a metric of the text output of a simulation to color, the line
+// starting with prefix, lower is better
+type colorMetric struct {
+	prefix string
+	value  func(sim simulation) float64
+}
+
+// colorMetrics the metrics to color
+var colorMetrics = []colorMetric{
+	{"Leadtime of tickets mean:", func(sim simulation) float64 {
+		m, _, _ := sim.statsLeadTime()
+		return m
+	}},
+	{"Max lateness of tickets:", func(sim simulation) float64 {
+		return float64(sim.maxLateness())
+	}},
+	{"Cost of delay", func(sim simulation) float64 {
+		return float64(sim.costOfDelay())
+	}},
+}
+
+// colorize color in the texts of the simulations per metric the line of the
+// best simulation green and of the worst red, nothing if all are equal
+func (simset simulationset) colorize(texts []string) []string {
+	lines := make([][]string, len(texts))
+	for i, text := range texts {
+		lines[i] = strings.Split(text, "\n")
+	}
+	for _, m := range colorMetrics {
+		best, worst := 0, 0
+		values := make([]float64, len(simset))
+		for i, s := range simset {
+			values[i] = m.value(s)
+			if values[i] < values[best] {
+				best = i
+			}
+			if values[i] > values[worst] {
+				worst = i
+			}
+		}
+		if values[best] == values[worst] {
+			continue
+		}
+		paint(lines[best], m.prefix, colorBest)
+		paint(lines[worst], m.prefix, colorWorst)
+	}
+	colored := make([]string, len(texts))
+	for i := range lines {
+		colored[i] = strings.Join(lines[i], "\n")
+	}
+	return colored
+}
+
+// paint color the first of the lines starting with prefix
+func paint(lines []string, prefix, color string) {
+	for i, l := range lines {
+		if strings.HasPrefix(l, prefix) {
+			lines[i] = color + l + colorReset
+			return
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// command a subcommand of wipsim selecting an analysis mode, its positional
+// arguments before the days set the option of the mode
+type command struct {
+	args        string // the positional arguments in the usage
+	description string
+	set         func(opts *options, args []string)
+	// flags register the flags of the mode besides those of the simulation,
+	// nil for none
+	flags func(opts *options)
+	// print print the output of a command without simulation to out, nil
+	// for the commands simulating
+	print func(out io.Writer)
+}
+
+// commands the subcommands by name in the order of the usage, without a
+// subcommand wipsim runs as run with the flags of all modes
+var commands = []struct {
+	name string
+	command
+}{
+	{"run", command{"", "run all strategies on the arrivals",
+		func(opts *options, args []string) {}, runFlags, nil}},
+	{"sweep", command{"lowest:highest",
+		"run all strategies, then rerun the pull strategy for each WIP limit" +
+			" from lowest to highest",
+		func(opts *options, args []string) { opts.wipSweep = args[0] }, nil,
+		nil}},
+	{"compare", command{"a.json b.json",
+		"run all strategies, then with each scenario file and print the change" +
+			" of the metrics from a to b",
+		func(opts *options, args []string) {
+			opts.compare = args[0] + "," + args[1]
+		}, nil, nil}},
+	{"forecast", command{"strategy",
+		"run all strategies, then forecast the leadtime of the strategy by an" +
+			" ensemble of runs with jittered WIP limit and capacity",
+		func(opts *options, args []string) { opts.ensemble = args[0] },
+		forecastFlags, nil}},
+	{"list-strategies", command{"",
+		"print the id, name and description of each registered strategy",
+		func(opts *options, args []string) {}, nil, listStrategies}},
+}
+
+// findCommand return the subcommand with name and true if it exists
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c.command, true
+		}
+	}
+	return command{}, false
+}
+
+// splitCommand return the subcommand of the arguments and the arguments
+// after it, an empty name and all arguments if the first is no subcommand
+func splitCommand(args []string) (string, []string) {
+	if len(args) > 0 {
+		if _, ok := findCommand(args[0]); ok {
+			return args[0], args[1:]
+		}
+	}
+	return "", args
+}
+
+// count return the count of positional arguments of the command before
+// the days
+func (c command) count() int {
+	return len(strings.Fields(c.args))
+}
+
+// usageCommands print the usage lines of the subcommands to out
+func usageCommands(out io.Writer, program string) {
+	fmt.Fprintln(out, "Commands:")
+	for _, c := range commands {
+		if c.print != nil {
+			fmt.Fprintf(out, "  %s %s\n    \t%s\n", program, c.name,
+				c.description)
+			continue
+		}
+		fmt.Fprintf(out, "  %s %s [flags] %s[days]\n    \t%s\n", program, c.name,
+			c.args+strings.Repeat(" ", min(len(c.args), 1)), c.description)
+	}
+}
+
+// listStrategies print the id, name and description of each registered
+// strategy to out
+func listStrategies(out io.Writer) {
+	for _, st := range strategies {
+		fmt.Fprintf(out, "  %-8s %s\n    \t%s\n", st.id, st.name, st.description)
+	}
+}
+
+// runFlags register the flags of the analyses and exports of the run mode
+func runFlags(opts *options) {
+	flag.StringVar(&opts.dumpTickets, "dump-tickets", "",
+		"write the records of all tickets of all strategies as CSV to `file`")
+	flag.IntVar(&opts.sensitivity, "sensitivity", 0,
+		"rerun without each ticket and report the `count` of tickets delaying"+
+			" the others most per strategy, 0 off")
+	flag.IntVar(&opts.sample, "sample-tickets", 0,
+		"print the records of a random sample of `count` tickets per strategy,"+
+			" 0 off")
+	flag.StringVar(&opts.wipTune, "wip-tune", "",
+		"search the WIP limit of the pull strategy in `lowest:highest`"+
+			" minimizing the 85% leadtime")
+	flag.StringVar(&opts.dumpRng, "dump-rng", "",
+		"write each random value drawn to create the tickets in order to `file`")
+	flag.StringVar(&opts.summary, "summary", "",
+		"write the done tickets and the sums of leadtimes and completion times"+
+			" per strategy as CSV to `file`")
+	flag.StringVar(&opts.weights, "weights", "",
+		"comma separated metric:weight of leadtime, p95, stability and gini"+
+			" to rank the strategies by a weighted score, e.g."+
+			" leadtime:0.5,p95:0.3,gini:0.2")
+	flag.BoolVar(&opts.memstats, "memstats", false,
+		"print the heap, the allocations and the bytes of the remaining work"+
+			" per day after the run to stderr")
+	flag.StringVar(&opts.grid, "grid", "",
+		"run all strategies for each combination of the `ranges`"+
+			" Field=lowest:highest:step, comma separated, of the numeric"+
+			" parameters named as in the manifest, see -grid-csv")
+	flag.StringVar(&opts.gridFile, "grid-csv", "grid.csv",
+		"write a row per combination of -grid and strategy as CSV to `file`")
+	flag.StringVar(&opts.tickets, "tickets", "",
+		"read the tickets to simulate from the CSV `file` of startday, effort and"+
+			" optional deadline and cost of delay per row instead of drawing them")
+	flag.StringVar(&opts.dumpOpen, "dump-open", "",
+		"write the remaining work of each open ticket per day and strategy as"+
+			" CSV to `file`, large for long runs")
+	flag.IntVar(&opts.seeds, "seeds", 0,
+		"rank the strategies by mean leadtime on `count` new seeds and print"+
+			" the win rate and average rank, 0 off")
+	flag.StringVar(&opts.minCapacity, "min-capacity", "",
+		"search the minimal hours per day for strategy `id` to meet the -sla"+
+			" on new arrival sets")
+	flag.BoolVar(&opts.efficiency, "efficiency", false,
+		"rerun shortest remaining work first hourly with the actual efforts and"+
+			" print the mean leadtime of each strategy as multiple of it")
+	flag.BoolVar(&opts.checksum, "checksum", false,
+		"print only the SHA-256 of the leadtimes of all tickets per strategy,"+
+			" for regression tests with a fixed -seed")
+}
+
+// forecastFlags register the flags of the ensemble of the forecast mode
+func forecastFlags(opts *options) {
+	flag.IntVar(&opts.ensembleCfg.runs, "ensemble-runs", 20,
+		"`count` of runs of the ensemble")
+	flag.IntVar(&opts.ensembleCfg.jitterWip, "jitter-wip", 1,
+		"maximum jitter of the WIP limit in `tickets` for the ensemble")
+	flag.Float64Var(&opts.ensembleCfg.jitterCapacity, "jitter-capacity", 0.5,
+		"maximum jitter of the capacity in `hours` for the ensemble")
+}
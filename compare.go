@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+)
+
+// metric a metric of a simulation to compare, lower is better unless higher
+type metric struct {
+	name   string
+	higher bool // higher values are better
+	value  func(sim simulation) float64
+}
+
+// compareMetrics the metrics compared per strategy
+var compareMetrics = []metric{
+	{"mean leadtime", false, func(sim simulation) float64 {
+		m, _, _ := sim.statsLeadTime()
+		return m
+	}},
+	{"85% leadtime", false, func(sim simulation) float64 {
+		return float64(sim.percentile(85))
+	}},
+	{"mean start delay", false, func(sim simulation) float64 {
+		return meanOf(sim.startDelays(false))
+	}},
+	{"max lateness", false, func(sim simulation) float64 {
+		return float64(sim.maxLateness())
+	}},
+	{"cost of delay", false, func(sim simulation) float64 {
+		return float64(sim.costOfDelay())
+	}},
+	{"throughput", true, func(sim simulation) float64 {
+		return sim.throughput()
+	}},
+}
+
+// runScenario read the scenario file over the parameters, create the
+// arrivals and simulate all strategies
+func runScenario(ctx context.Context, p Params, file string) (simulationset,
+	error) {
+	format := ""
+	if err := readScenario(file, &p, &format); err != nil {
+		return nil, err
+	}
+	if p.Days == 0 && p.ArrivalModel == arrivalBatch {
+		p.Days = batchDays(&p)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	smp := newSampler(rand.New(rand.NewSource(p.Seed)), &p)
+	arrivals, _, _ := createArrivals(&p, smp, io.Discard)
+	simset, err := Run(ctx, &p, arrivals)
+	return simset, warnDrain("compare "+file, err)
+}
+
+// compare run the strategies with the parameters of the scenario files a and
+// b over the given parameters and return a table of the metrics per strategy
+// of both, the difference and the percent change of b to a, marked better or
+// worse. If the context is cancelled return the error.
+func compare(ctx context.Context, p *Params, a, b string) (string, error) {
+	simsetA, err := runScenario(ctx, *p, a)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", a, err)
+	}
+	simsetB, err := runScenario(ctx, *p, b)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", b, err)
+	}
+	places := p.Precision
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("Comparison of A: %s and B: %s\n", a, b))
+	for _, sa := range simsetA {
+		sb, ok := simsetB.find(sa.id)
+		if !ok {
+			continue
+		}
+		buf.WriteString(fmt.Sprintln(sa.name))
+		buf.WriteString(fmt.Sprintf("%-16s %10s %10s %10s %8s\n",
+			"# metric", "A", "B", "B-A", "change"))
+		for _, m := range compareMetrics {
+			va, vb := m.value(sa), m.value(sb)
+			delta := vb - va
+			change := "n/a"
+			if va != 0 && !math.IsNaN(va) {
+				change = fmt.Sprintf(withPrecision("%+.1f%%", places), 100*delta/math.Abs(va))
+			}
+			mark := ""
+			if delta != 0 && (delta > 0) == m.higher {
+				mark = " better"
+			} else if delta != 0 {
+				mark = " worse"
+			}
+			buf.WriteString(fmt.Sprintf(withPrecision(
+				"%-16s %10.2f %10.2f %+10.2f %8s%s\n", places),
+				m.name, va, vb, delta, change, mark))
+		}
+	}
+	return buf.String(), nil
+}
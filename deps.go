@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+)
+
+// depWindow the count of tickets created before a ticket it may depend on
+const depWindow = 10
+
+// addDependencies let each ticket of the arrivals depend with probability
+// p.Dependencies on one of the depWindow tickets created before it.
+// The dependencies are seeded from the seed of the parameters.
+func addDependencies(p *Params, arrivals [][]*ticket) {
+	rng := rand.New(rand.NewSource(p.Seed + 4))
+	for _, tickets := range arrivals {
+		for _, t := range tickets {
+			if t.seq == 0 || rng.Float64() >= p.Dependencies {
+				continue
+			}
+			lowest := t.seq - depWindow
+			if lowest < 0 {
+				lowest = 0
+			}
+			t.prereqs = append(t.prereqs, lowest+rng.Intn(t.seq-lowest))
+		}
+	}
+}
+
+// resolveDeps resolve the prerequisites of the ticket to the tickets of the
+// simulation by their sequence, prerequisites rejected at intake are dropped
+func (sim *simulation) resolveDeps(t *ticket) {
+	for _, seq := range t.prereqs {
+		if pre, ok := sim.bySeq[seq]; ok {
+			t.deps = append(t.deps, pre)
+		}
+	}
+}
+
+// blocked reports whether a prerequisite of the ticket has remaining work
+// during day
+func (t *ticket) blocked(day int) bool {
+	for _, pre := range t.deps {
+		if pre.current(day) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// dependencyReport create the report of the mean days the tickets with
+// prerequisites were blocked and the mean leadtime of the tickets with and
+// without prerequisites
+func (sim simulation) dependencyReport() string {
+	places := sim.params.Precision
+	var with, without, blocked []int
+	for _, t := range sim.tickets {
+		if t.cancelled {
+			continue
+		}
+		if len(t.deps) > 0 {
+			with = append(with, t.leadtime)
+			blocked = append(blocked, t.blockeddays)
+		} else {
+			without = append(without, t.leadtime)
+		}
+	}
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("Tickets with prerequisites: %d, blocked days"+
+		" mean: %s\n", len(with), meanOrNA("%.2f", blocked, places)))
+	buf.WriteString(fmt.Sprintf("Leadtime mean with prerequisites: %s"+
+		" without: %s\n", meanOrNA("%.2f", with, places),
+		meanOrNA("%.2f", without, places)))
+	return buf.String()
+}
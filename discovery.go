@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+)
+
+// addMisestimates give each ticket of the arrivals a misestimate, the
+// believed effort at arrival minus the effort, normal with a standard
+// deviation of p.EffortDiscovery times the effort. The believed effort is at
+// least 1 h. The misestimates are drawn per ticket, see ticketNorm.
+func addMisestimates(p *Params, arrivals [][]*ticket) {
+	for _, tickets := range arrivals {
+		for _, t := range tickets {
+			z := ticketNorm(p.Seed, streamDiscovery, t, 0)
+			e := math.Round(float64(t.effort) * p.EffortDiscovery * z)
+			t.misestimate = max(int(e), 1-t.effort)
+		}
+	}
+}
+
+// believed return the remaining work of the ticket at day as believed, the
+// misestimate shrinks with the work done and is gone when the ticket is done
+func (t *ticket) believed(day int) int {
+	current := t.current(day)
+	if t.misestimate == 0 || current == 0 {
+		return current
+	}
+	share := float64(current) / float64(t.effort)
+	return max(current+int(math.Round(float64(t.misestimate)*share)), 1)
+}
+
+// withoutMisestimates return copies of the tickets of the arrivals with the
+// effort known at arrival
+func withoutMisestimates(arrivals [][]*ticket) [][]*ticket {
+	known := make([][]*ticket, len(arrivals))
+	for d, tickets := range arrivals {
+		known[d] = make([]*ticket, len(tickets))
+		for i, t := range tickets {
+			known[d][i] = t.Clone()
+			known[d][i].misestimate = 0
+		}
+	}
+	return known
+}
+
+// discoveryReport rerun the strategies on the arrivals with the effort known
+// at arrival and return per strategy the mean leadtime with the effort
+// discovered while working and known and the increase, the cost of the
+// misestimates. If the context is cancelled return the error.
+func discoveryReport(ctx context.Context, p *Params, arrivals [][]*ticket,
+	simset simulationset) (string, error) {
+	places := p.Precision
+	known, err := Run(ctx, p, withoutMisestimates(arrivals))
+	if err = warnDrain("discovery", err); err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("Cost of effort discovery with misestimates"+
+		" of stdev %.0f%% of the effort\n", 100*p.EffortDiscovery))
+	buf.WriteString("# strategy: mean leadtime with effort discovered known" +
+		" increase\n")
+	for i, s := range simset {
+		discovered, _, _ := s.statsLeadTime()
+		exact, _, _ := known[i].statsLeadTime()
+		buf.WriteString(fmt.Sprintf("%s: %s %s %s\n", s.name,
+			orNA("%.2f", discovered, places), orNA("%.2f", exact, places),
+			orNA("%+.1f%%", 100*(discovered-exact)/exact, places)))
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// ensembleConfig the configuration of an ensemble of runs of a strategy
+type ensembleConfig struct {
+	runs           int     // count of runs
+	jitterWip      int     // maximum jitter of the WIP limit in tickets
+	jitterCapacity float64 // maximum jitter of the capacity in hours
+}
+
+// ensemble rerun the strategy with id on the same arrivals with the WIP limit
+// and the capacity jittered uniformly. The jitter is seeded from the seed of
+// the parameters. Return a report of the spread of the mean leadtime.
+// If the context is cancelled return the error.
+func ensemble(ctx context.Context, p *Params, arrivals [][]*ticket, id string,
+	cfg ensembleConfig) (string, error) {
+	places := p.Precision
+	st, ok := findStrategy(id)
+	if !ok {
+		return "", fmt.Errorf("unknown strategy %s", id)
+	}
+	rng := rand.New(rand.NewSource(p.Seed + 1))
+	sz := p.Days * 3 / 2 // some more size avoid reallocation
+	simset := make(simulationset, cfg.runs)
+	for i := range simset {
+		pj := *p
+		pj.WipLimit += rng.Intn(2*cfg.jitterWip+1) - cfg.jitterWip
+		if pj.WipLimit < 1 {
+			pj.WipLimit = 1
+		}
+		pj.Capacity += (2*rng.Float64() - 1) * cfg.jitterCapacity
+		if pj.Capacity < 0 {
+			pj.Capacity = 0
+		}
+		simset[i] = NewSimulation(st, &pj, sz)
+	}
+	simset, err := simset.run(ctx, arrivals)
+	if err = warnDrain("ensemble", err); err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	frmt := withPrecision("Ensemble of %s, %d runs, WIP %d ±%d,"+
+		" capacity %.2f ±%.2f h\n", places)
+	buf.WriteString(fmt.Sprintf(frmt, st.name, cfg.runs, p.WipLimit,
+		cfg.jitterWip, p.Capacity, cfg.jitterCapacity))
+	var sum, sumSq float64
+	lowest, highest := math.Inf(1), math.Inf(-1)
+	for _, s := range simset {
+		m, _, _ := s.statsLeadTime()
+		sum += m
+		sumSq += m * m
+		lowest = math.Min(lowest, m)
+		highest = math.Max(highest, m)
+	}
+	n := float64(len(simset))
+	mean := sum / n
+	stdev := math.Sqrt(math.Max(sumSq/n-mean*mean, 0))
+	frmt = withPrecision("Mean leadtime mean: %.2f stdev: %.2f min: %.2f"+
+		" max: %.2f\n", places)
+	buf.WriteString(fmt.Sprintf(frmt, mean, stdev, lowest, highest))
+	if len(simset) <= maxPrint {
+		buf.WriteString("# wip capacity mean-leadtime\n")
+		for _, s := range simset {
+			m, _, _ := s.statsLeadTime()
+			buf.WriteString(fmt.Sprintf(withPrecision("%d %.2f %.2f\n", places),
+				s.params.WipLimit, s.params.Capacity, m))
+		}
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// addExpedites add to each day of the arrivals with probability
+// p.ExpediteRate an expedite ticket of p.ExpediteEffort due the same day.
+// The expedites are seeded from the seed of the parameters.
+func addExpedites(p *Params, arrivals [][]*ticket) {
+	rng := rand.New(rand.NewSource(p.Seed + 7))
+	horizon := p.horizon()
+	for d := 0; d < p.Days; d++ {
+		if rng.Float64() >= p.ExpediteRate {
+			continue
+		}
+		t := NewTicket(d, p.ExpediteEffort, horizon)
+		t.deadline = d
+		t.costofdelay = int(math.Round(p.MeanCostOfDelay))
+		t.expedite = true
+		t.seq = -1
+		arrivals[d] = append(arrivals[d], t)
+	}
+}
+
+// withoutExpedites return the arrivals without the expedite tickets
+func withoutExpedites(arrivals [][]*ticket) [][]*ticket {
+	normal := make([][]*ticket, len(arrivals))
+	for d, tickets := range arrivals {
+		normal[d] = make([]*ticket, 0, len(tickets))
+		for _, t := range tickets {
+			if !t.expedite {
+				normal[d] = append(normal[d], t)
+			}
+		}
+	}
+	return normal
+}
+
+// burnExpedites burn down the open expedite tickets oldest first with all
+// hours of the current slot of day, return the hours used
+func (sim *simulation) burnExpedites(day int) int {
+	hours := sim.slotHours(day)
+	hoursleft := hours
+	for _, t := range sim.tickets {
+		if t.expedite && t.current(day) > 0 {
+			hoursleft = sim.burn(t, day, hoursleft, hoursleft)
+		}
+	}
+	return hours - hoursleft
+}
+
+// normalLeadtime return the mean leadtime of the tickets not expedited
+func (sim simulation) normalLeadtime() float64 {
+	lts := make([]int, 0, len(sim.tickets))
+	for _, t := range sim.tickets {
+		if !t.expedite && !t.cancelled {
+			lts = append(lts, t.leadtime)
+		}
+	}
+	return meanOf(lts)
+}
+
+// expediteReport rerun the strategies on the arrivals without the expedite
+// tickets and return per strategy the mean leadtime of the normal tickets
+// with and without the expedites and the increase, the cost of the
+// interruptions. If the context is cancelled return the error.
+func expediteReport(ctx context.Context, p *Params, arrivals [][]*ticket,
+	simset simulationset) (string, error) {
+	places := p.Precision
+	calm, err := Run(ctx, p, withoutExpedites(arrivals))
+	if err = warnDrain("expedite", err); err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	buf.WriteString("Cost of interruptions by expedite tickets\n")
+	buf.WriteString("# strategy: mean leadtime of normal tickets with expedites" +
+		" without increase\n")
+	for i, s := range simset {
+		with, without := s.normalLeadtime(), calm[i].normalLeadtime()
+		buf.WriteString(fmt.Sprintf("%s: %s %s %s\n", s.name,
+			orNA("%.2f", with, places), orNA("%.2f", without, places),
+			orNA("%+.1f%%", 100*(with-without)/without, places)))
+	}
+	return buf.String(), nil
+}
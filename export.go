@@ -0,0 +1,312 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dumpTickets write the records of all tickets of each simulation as CSV
+// to the file, the ticket by ticketID. firstwork and end are empty for tickets
+// not worked or not done.
+func dumpTickets(filename string, simset simulationset) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	header := []string{"ticket_id", "strategy", "start", "firstwork", "end",
+		"leadtime", "effort"}
+	if err := w.Write(header); err != nil {
+		f.Close()
+		return err
+	}
+	for _, s := range simset {
+		for _, t := range s.tickets {
+			firstwork := ""
+			if t.firstwork >= 0 {
+				firstwork = strconv.Itoa(t.firstwork)
+			}
+			end := ""
+			if t.done(s.lastday) {
+				end = strconv.Itoa(t.endday)
+			}
+			record := []string{ticketID(t), s.name,
+				strconv.Itoa(t.startday), firstwork, end,
+				strconv.Itoa(t.leadtime), strconv.Itoa(t.effort)}
+			if err := w.Write(record); err != nil {
+				f.Close()
+				return err
+			}
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// influxTagEscaper escape the special characters of a tag value
+var influxTagEscaper = strings.NewReplacer(",", "\\,", "=", "\\=", " ", "\\ ")
+
+// writeInflux write the metrics per day of each simulation in the InfluxDB
+// line protocol, the first day at start, the following days a day apart
+func writeInflux(w io.Writer, simset simulationset, start time.Time) error {
+	for _, s := range simset {
+		tag := influxTagEscaper.Replace(s.name)
+		wip := s.wipPerDay()
+		queue := s.queuePerDay()
+		backlog := s.backlogHours()
+		completed := s.completedPerDay()
+		for d := range wip {
+			ts := start.AddDate(0, 0, d).UnixNano()
+			_, err := fmt.Fprintf(w,
+				"wipsim,strategy=%s wip=%di,queue=%di,backlog_hours=%di,"+
+					"completed=%di %d\n",
+				tag, wip[d], queue[d], backlog[d], completed[d], ts)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeScatter write effort and leadtime of the done tickets of each
+// simulation, a block per simulation separated by two empty lines,
+// as index for gnuplot
+func writeScatter(w io.Writer, simset simulationset) error {
+	for i, s := range simset {
+		if i > 0 {
+			if _, err := fmt.Fprint(w, "\n\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "# %s\n# effort leadtime\n", s.name); err != nil {
+			return err
+		}
+		for _, t := range s.tickets {
+			if t.effort == 0 || !t.done(s.lastday) {
+				continue
+			}
+			if _, err := fmt.Fprintln(w, t.effort, t.leadtime); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeSummary write per simulation the count of done tickets, the mean
+// leadtime and the sums of the leadtimes and of the completion times of the
+// done tickets as CSV to the file
+func writeSummary(filename string, simset simulationset) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	header := []string{"strategy", "done", "mean_leadtime", "sum_leadtime",
+		"sum_completion"}
+	if err := w.Write(header); err != nil {
+		f.Close()
+		return err
+	}
+	for _, s := range simset {
+		m, _, _ := s.statsLeadTime()
+		done, sumLead, sumCompletion := s.sumCompletionTimes()
+		record := []string{s.name, strconv.Itoa(done),
+			strconv.FormatFloat(m, 'f', 4, 64), strconv.Itoa(sumLead),
+			strconv.Itoa(sumCompletion)}
+		if err := w.Write(record); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeMarkdown write per simulation the leadtime mean, stdev, 85th and 95th
+// percentile, the throughput and the maximal WIP as GitHub flavored Markdown
+// table, the columns padded to align
+func writeMarkdown(w io.Writer, simset simulationset, places int) error {
+	rows := [][]string{{"strategy", "mean", "stdev", "p85", "p95",
+		"throughput", "max WIP"}}
+	for _, s := range simset {
+		m, sd, _ := s.statsLeadTime()
+		p85, p95 := math.NaN(), math.NaN()
+		if len(s.leadtimes()) > 0 {
+			p85, p95 = float64(s.percentile(85)), float64(s.percentile(95))
+		}
+		maxWip := 0
+		for _, wip := range s.wipPerDay() {
+			maxWip = max(maxWip, wip)
+		}
+		rows = append(rows, []string{s.name,
+			orNA("%.2f", m, places), orNA("%.2f", sd, places),
+			orNA("%.2f", p85, places), orNA("%.2f", p95, places),
+			fmt.Sprintf(withPrecision("%.2f", places), s.throughput()),
+			strconv.Itoa(maxWip)})
+	}
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell), 3)
+		}
+	}
+	separator := make([]string, len(widths))
+	separator[0] = ":" + strings.Repeat("-", widths[0]-1)
+	for i := 1; i < len(widths); i++ {
+		separator[i] = strings.Repeat("-", widths[i]-1) + ":"
+	}
+	rows = append(rows[:1], append([][]string{separator}, rows[1:]...)...)
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			if i == 0 {
+				cells[i] = fmt.Sprintf("%-*s", widths[i], cell)
+			} else {
+				cells[i] = fmt.Sprintf("%*s", widths[i], cell)
+			}
+		}
+		if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resultChecksum return the hex SHA-256 of the leadtimes of all tickets of
+// each simulation, a line per simulation with its id and the leadtimes
+func resultChecksum(simset simulationset) string {
+	h := sha256.New()
+	for _, s := range simset {
+		fmt.Fprint(h, s.id)
+		for _, t := range s.tickets {
+			fmt.Fprint(h, " ", t.leadtime)
+		}
+		fmt.Fprintln(h)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeEffortCompletions write the count of tickets done per day and effort
+// bucket of each simulation, a block per simulation separated by two empty
+// lines, as index for gnuplot
+func writeEffortCompletions(w io.Writer, simset simulationset) error {
+	for i, s := range simset {
+		if i > 0 {
+			if _, err := fmt.Fprint(w, "\n\n"); err != nil {
+				return err
+			}
+		}
+		header := make([]string, len(effortBuckets)+1)
+		for b := range header {
+			header[b] = bucketName(b)
+		}
+		if _, err := fmt.Fprintf(w, "# %s\n# day %s\n", s.name,
+			strings.Join(header, " ")); err != nil {
+			return err
+		}
+		completed := make([][]int, s.lastday+1)
+		for d := range completed {
+			completed[d] = make([]int, len(effortBuckets)+1)
+		}
+		for _, t := range s.tickets {
+			if t.effort > 0 && t.done(s.lastday) {
+				completed[t.endday][effortBucket(t.effort)]++
+			}
+		}
+		for d, counts := range completed {
+			line := fmt.Sprint(d)
+			for _, c := range counts {
+				line += fmt.Sprint(" ", c)
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// snapshotWarnRows the count of rows of the snapshots to warn about the size
+const snapshotWarnRows = 1000000
+
+// ticketID return the id of the ticket in the exports, its seq, the same in
+// all strategies, for an expedite ticket e and its start day
+func ticketID(t *ticket) string {
+	if t.expedite {
+		return "e" + strconv.Itoa(t.startday)
+	}
+	return strconv.Itoa(t.seq)
+}
+
+// snapshotRows return the count of rows dumpSnapshots writes for the
+// simulations, the tickets open per day summed over the days
+func (simset simulationset) snapshotRows() int {
+	rows := 0
+	for _, s := range simset {
+		for d := 0; d <= s.lastday; d++ {
+			for _, t := range s.tickets {
+				if t.startday <= d && t.remaining[d] > 0 {
+					rows++
+				}
+			}
+		}
+	}
+	return rows
+}
+
+// dumpSnapshots write for each simulation, day and ticket open at the day
+// the remaining work as CSV to the file, the full state trajectory. The
+// ticket is identified by ticketID. Return the count of rows written.
+func dumpSnapshots(filename string, simset simulationset) (int, error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return 0, err
+	}
+	w := csv.NewWriter(f)
+	if err := w.Write([]string{"day", "strategy", "ticket_id",
+		"remaining"}); err != nil {
+		f.Close()
+		return 0, err
+	}
+	rows := 0
+	for _, s := range simset {
+		for d := 0; d <= s.lastday; d++ {
+			for _, t := range s.tickets {
+				if t.startday > d || t.remaining[d] == 0 {
+					continue
+				}
+				record := []string{strconv.Itoa(d), s.name, ticketID(t),
+					strconv.Itoa(t.remaining[d])}
+				if err := w.Write(record); err != nil {
+					f.Close()
+					return rows, err
+				}
+				rows++
+			}
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return rows, err
+	}
+	return rows, f.Close()
+}
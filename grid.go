@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// gridAxis a numeric field of the parameters and the values to run it with
+type gridAxis struct {
+	field  string
+	values []float64
+}
+
+// parseGrid parse the grid of comma separated Field=lowest:highest:step,
+// each field a numeric field of the parameters named as in the manifest
+func parseGrid(spec string) ([]gridAxis, error) {
+	var axes []gridAxis
+	pt := reflect.TypeOf(Params{})
+	for _, part := range strings.Split(spec, ",") {
+		field, r, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q not of form Field=lowest:highest:step", part)
+		}
+		f, ok := pt.FieldByName(field)
+		if !ok {
+			return nil, fmt.Errorf("unknown parameter %s", field)
+		}
+		switch f.Type.Kind() {
+		case reflect.Int, reflect.Int64, reflect.Float64:
+		default:
+			return nil, fmt.Errorf("parameter %s is not numeric", field)
+		}
+		bounds := strings.Split(r, ":")
+		if len(bounds) != 3 {
+			return nil, fmt.Errorf("range %q not of form lowest:highest:step", r)
+		}
+		var lo, hi, step float64
+		for i, v := range []*float64{&lo, &hi, &step} {
+			var err error
+			if *v, err = strconv.ParseFloat(bounds[i], 64); err != nil {
+				return nil, err
+			}
+		}
+		if step <= 0 || hi < lo {
+			return nil, fmt.Errorf("range %q not lowest <= highest, step > 0", r)
+		}
+		axis := gridAxis{field: field}
+		for i := 0; lo+float64(i)*step <= hi+step*1e-9; i++ {
+			axis.values = append(axis.values, lo+float64(i)*step)
+		}
+		axes = append(axes, axis)
+	}
+	return axes, nil
+}
+
+// set set the field of the axis in the parameters to v, integer fields
+// rounded
+func (axis gridAxis) set(p *Params, v float64) {
+	f := reflect.ValueOf(p).Elem().FieldByName(axis.field)
+	if f.Kind() == reflect.Float64 {
+		f.SetFloat(v)
+	} else {
+		f.SetInt(int64(math.Round(v)))
+	}
+}
+
+// runGrid run all strategies for each combination of the values of the axes
+// over the parameters and write a CSV row of the values and the compared
+// metrics per combination and strategy to file. The arrivals of each
+// combination are created from the same seed. Return the count of the
+// combinations, if the context is cancelled the error.
+func runGrid(ctx context.Context, p *Params, axes []gridAxis,
+	file string) (int, error) {
+	f, err := os.Create(file)
+	if err != nil {
+		return 0, err
+	}
+	w := csv.NewWriter(f)
+	header := make([]string, 0, len(axes)+1+len(compareMetrics))
+	for _, axis := range axes {
+		header = append(header, axis.field)
+	}
+	header = append(header, "strategy")
+	for _, m := range compareMetrics {
+		header = append(header, strings.ReplaceAll(m.name, " ", "_"))
+	}
+	if err := w.Write(header); err != nil {
+		f.Close()
+		return 0, err
+	}
+	// index of the value per axis, the last axis varies fastest
+	index := make([]int, len(axes))
+	count := 0
+	for {
+		pg := *p
+		values := make([]string, len(axes))
+		for i, axis := range axes {
+			axis.set(&pg, axis.values[index[i]])
+			values[i] = strconv.FormatFloat(axis.values[index[i]], 'g', -1, 64)
+		}
+		if err := writeGridRows(ctx, w, &pg, values); err != nil {
+			f.Close()
+			return count, fmt.Errorf("%s: %w", strings.Join(values, ","), err)
+		}
+		count++
+		i := len(axes) - 1
+		for ; i >= 0; i-- {
+			index[i]++
+			if index[i] < len(axes[i].values) {
+				break
+			}
+			index[i] = 0
+		}
+		if i < 0 {
+			break
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return count, err
+	}
+	return count, f.Close()
+}
+
+// writeGridRows simulate all strategies with the parameters and write a row
+// per strategy of the values of the combination and the compared metrics
+func writeGridRows(ctx context.Context, w *csv.Writer, p *Params,
+	values []string) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	smp := newSampler(rand.New(rand.NewSource(p.Seed)), p)
+	arrivals, _, _ := createArrivals(p, smp, io.Discard)
+	simset, err := Run(ctx, p, arrivals)
+	if err = warnDrain("grid", err); err != nil {
+		return err
+	}
+	for _, s := range simset {
+		record := append([]string{}, values...)
+		record = append(record, s.id)
+		for _, m := range compareMetrics {
+			record = append(record, strconv.FormatFloat(m.value(s), 'f', 4, 64))
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
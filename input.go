@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+)
+
+// readTicketsFile read the arrivals of the simulation from the CSV file,
+// see readTickets
+func readTicketsFile(file string, p *Params) ([][]*ticket, int, int,
+	[]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, 0, 0, nil, err
+	}
+	defer f.Close()
+	return readTickets(f, p)
+}
+
+// readTickets read the arrivals of the simulation from CSV rows of
+// startday, effort and optionally deadline and cost of delay, lines starting
+// with # are comments. The deadline defaults to the due date of the effort,
+// the cost of delay to the mean cost of delay. Return the tickets per day,
+// the count of tickets, the sum of effort and a warning for each row
+// duplicating an earlier one. All bad rows are reported by line in the error.
+func readTickets(r io.Reader, p *Params) ([][]*ticket, int, int,
+	[]string, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	arrivals := make([][]*ticket, p.horizon())
+	sumCount, sumEffort := 0, 0
+	var errs []error
+	var warnings []string
+	seen := make(map[[4]int]int) // line of the first row of each ticket
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := cr.FieldPos(0)
+		if err != nil {
+			var perr *csv.ParseError
+			if !errors.As(err, &perr) {
+				return nil, 0, 0, nil, err
+			}
+			errs = append(errs, err)
+			continue
+		}
+		spec, err := parseTicketRow(row, p)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", line, err))
+			continue
+		}
+		if first, ok := seen[spec]; ok {
+			warnings = append(warnings,
+				fmt.Sprintf("line %d duplicates line %d", line, first))
+		} else {
+			seen[spec] = line
+		}
+		t := NewTicket(spec[0], spec[1], p.horizon())
+		t.deadline = spec[2]
+		t.costofdelay = spec[3]
+		arrivals[spec[0]] = append(arrivals[spec[0]], t)
+		sumCount++
+		sumEffort += spec[1]
+	}
+	if len(errs) > 0 {
+		return nil, 0, 0, warnings, errors.Join(errs...)
+	}
+	seq := 0
+	for _, tickets := range arrivals {
+		for _, t := range tickets {
+			t.seq = seq
+			seq++
+		}
+	}
+	decorateArrivals(p, arrivals)
+	return arrivals, sumCount, sumEffort, warnings, nil
+}
+
+// parseTicketRow parse and check a row of the tickets file, return the
+// startday, effort, deadline and cost of delay of the ticket
+func parseTicketRow(row []string, p *Params) ([4]int, error) {
+	var spec [4]int
+	if len(row) < 2 || len(row) > len(spec) {
+		return spec, fmt.Errorf("want 2 to %d fields, got %d", len(spec),
+			len(row))
+	}
+	for i, field := range row {
+		v, err := strconv.Atoi(field)
+		if err != nil {
+			return spec, err
+		}
+		spec[i] = v
+	}
+	startday, effort := spec[0], spec[1]
+	if startday < 0 || startday >= p.Days {
+		return spec, fmt.Errorf("startday %d not in [0, %d)", startday, p.Days)
+	}
+	if effort < max(p.MinEffort, 1) {
+		return spec, fmt.Errorf("effort %d below the min effort %d", effort,
+			max(p.MinEffort, 1))
+	}
+	if len(row) < 3 {
+		spec[2] = duedate(startday, effort, p.DueFactor)
+	} else if spec[2] < startday {
+		return spec, fmt.Errorf("deadline %d before startday %d", spec[2],
+			startday)
+	}
+	if len(row) < 4 {
+		spec[3] = int(math.Round(p.MeanCostOfDelay))
+	} else if spec[3] < 0 {
+		return spec, fmt.Errorf("cost of delay %d is negative", spec[3])
+	}
+	return spec, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// version the version of wipsim
+const version = "0.2.0"
+
+// manifest the record to reproduce a run
+type manifest struct {
+	Version    string
+	Params     Params
+	Tickets    int    // count of tickets generated
+	TicketHash string // sha256 of the generated tickets
+}
+
+// newManifest create the manifest of a run with the parameters
+// and the generated arrivals
+func newManifest(p *Params, arrivals [][]*ticket) manifest {
+	m := manifest{}
+	m.Version = version
+	m.Params = *p
+	m.Tickets, m.TicketHash = hashTickets(arrivals)
+	return m
+}
+
+// hashTickets return the count and the sha256 of the tickets in order of
+// arrival, each as startday, effort, deadline and cost of delay
+func hashTickets(arrivals [][]*ticket) (int, string) {
+	h := sha256.New()
+	count := 0
+	for _, tickets := range arrivals {
+		for _, t := range tickets {
+			fmt.Fprintln(h, t.startday, t.effort, t.deadline, t.costofdelay)
+			count++
+		}
+	}
+	return count, hex.EncodeToString(h.Sum(nil))
+}
+
+// JSON return the manifest as indented JSON
+func (m manifest) JSON() ([]byte, error) {
+	return json.MarshalIndent(m, "", "  ")
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+)
+
+// mib the bytes of a mebibyte
+const mib = 1 << 20
+
+// memReport return the heap in use and obtained from the system, the total
+// bytes and count of allocations of the process so far, and the bytes of the
+// remaining work per day of the tickets of all simulations
+func memReport(simset simulationset) string {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	remaining := 0
+	for _, s := range simset {
+		for _, t := range s.tickets {
+			remaining += cap(t.remaining)
+		}
+	}
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("Memory heap in use: %.1f MiB, peak obtained"+
+		" from the system: %.1f MiB\n", float64(ms.HeapAlloc)/mib,
+		float64(ms.HeapSys)/mib))
+	buf.WriteString(fmt.Sprintf("Allocations total: %.1f MiB in %d, garbage"+
+		" collections: %d\n", float64(ms.TotalAlloc)/mib, ms.Mallocs, ms.NumGC))
+	buf.WriteString(fmt.Sprintf("Remaining work per day of all tickets: %.1f"+
+		" MiB\n", float64(remaining*strconv.IntSize/8)/mib))
+	return buf.String()
+}
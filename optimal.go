@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+)
+
+// srptLeadtime return the mean leadtime of shortest remaining work first on
+// the arrivals reprioritized each hour of the capacity. Shortest remaining
+// processing time (SRPT) is the optimal mean flow time of preemptive work
+// with release dates, the optimum for the ticket set up to the days. It
+// knows the actual remaining work, the misestimates are removed.
+// If the context is cancelled return the error.
+func srptLeadtime(ctx context.Context, p *Params,
+	arrivals [][]*ticket) (float64, error) {
+	st, _ := findStrategy("sjf")
+	pr := *p
+	pr.Resolution = max(int(math.Round(p.effectiveCapacity())), 1)
+	simset := simulationset{NewSimulation(st, &pr, p.Days*3/2)}
+	simset, err := simset.run(ctx, withoutMisestimates(arrivals))
+	if err = warnDrain("efficiency", err); err != nil {
+		return math.NaN(), err
+	}
+	m, _, _ := simset[0].statsLeadTime()
+	return m, nil
+}
+
+// efficiencyReport return per strategy the mean leadtime as multiple of the
+// optimal mean leadtime of the arrivals, that of SRPT hourly, 1 is optimal.
+// A multiple below 1 is possible as the days round the leadtimes.
+// If the context is cancelled return the error.
+func efficiencyReport(ctx context.Context, p *Params, arrivals [][]*ticket,
+	simset simulationset) (string, error) {
+	places := p.Precision
+	optimum, err := srptLeadtime(ctx, p, arrivals)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("Efficiency vs optimal mean leadtime %s"+
+		" (SRPT hourly)\n# strategy: multiple of optimal\n",
+		orNA("%.2f", optimum, places)))
+	for _, s := range simset {
+		m, _, _ := s.statsLeadTime()
+		buf.WriteString(fmt.Sprintf("%s: %s\n", s.name,
+			orNA("%.2fx", m/optimum, places)))
+	}
+	return buf.String(), nil
+}
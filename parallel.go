@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+)
+
+// setParallel give each ticket of the arrivals the parallelizable fraction
+// of its effort of the parameters
+func setParallel(p *Params, arrivals [][]*ticket) {
+	for _, tickets := range arrivals {
+		for _, t := range tickets {
+			t.parallel = p.Parallel
+		}
+	}
+}
+
+// parallelCap return the maximum hours the ticket takes per day by Amdahl's
+// law, the hours of a worker sped up by the workers of the capacity on the
+// parallelizable fraction only, at least 1
+func (sim *simulation) parallelCap(t *ticket) int {
+	workers := max(sim.params.effectiveCapacity()/workhoursday, 1)
+	speedup := 1 / (1 - t.parallel + t.parallel/workers)
+	return max(int(math.Floor(workhoursday*speedup)), 1)
+}
+
+// withoutSerial return copies of the tickets of the arrivals with all of the
+// effort parallelizable
+func withoutSerial(arrivals [][]*ticket) [][]*ticket {
+	parallel := make([][]*ticket, len(arrivals))
+	for d, tickets := range arrivals {
+		parallel[d] = make([]*ticket, len(tickets))
+		for i, t := range tickets {
+			parallel[d][i] = t.Clone()
+			parallel[d][i].parallel = 1
+		}
+	}
+	return parallel
+}
+
+// parallelReport rerun the strategies on the arrivals with all of the effort
+// parallelizable and return per strategy the mean leadtime with the serial
+// part and without and the increase, the cost of the serial work. If the
+// context is cancelled return the error.
+func parallelReport(ctx context.Context, p *Params, arrivals [][]*ticket,
+	simset simulationset) (string, error) {
+	places := p.Precision
+	free, err := Run(ctx, p, withoutSerial(arrivals))
+	if err = warnDrain("parallel", err); err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("Cost of serial work with %.0f%% of the effort"+
+		" parallelizable, at most %d h per ticket and day\n", 100*p.Parallel,
+		simset[0].parallelCap(&ticket{parallel: p.Parallel})))
+	buf.WriteString("# strategy: mean leadtime with serial work without" +
+		" increase\n")
+	for i, s := range simset {
+		serial, _, _ := s.statsLeadTime()
+		parallel, _, _ := free[i].statsLeadTime()
+		buf.WriteString(fmt.Sprintf("%s: %s %s %s\n", s.name,
+			orNA("%.2f", serial, places), orNA("%.2f", parallel, places),
+			orNA("%+.1f%%", 100*(serial-parallel)/parallel, places)))
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// reworkShare the share of the effort of a ticket reopened as rework
+const reworkShare = 0.5
+
+// reworkProbability return the probability a ticket done with wip tickets
+// open is reopened, Params.Rework growing by Params.ReworkWip per open ticket
+func (p *Params) reworkProbability(wip int) float64 {
+	return math.Min(p.Rework+p.ReworkWip*float64(wip), 1)
+}
+
+// reworking reports whether done tickets may be reopened
+func (p *Params) reworking() bool {
+	return p.Rework > 0 || p.ReworkWip > 0
+}
+
+// reopen reopen the ticket done on day by the rework probability with the
+// rework share of its effort as remaining work. The roll of each completion
+// of the ticket is drawn per ticket, see ticketDraw.
+func (sim *simulation) reopen(t *ticket, day int) {
+	wip := len(sim.openTickets(day)) + 1
+	roll := ticketDraw(sim.params.Seed, streamRework, t, t.reworks)
+	if roll >= sim.params.reworkProbability(wip) {
+		sim.doneWip.add(float64(wip))
+		return
+	}
+	sim.reworkWip.add(float64(wip))
+	t.reworks++
+	t.remaining[day+1] = max(int(math.Round(reworkShare*float64(t.effort))), 1)
+}
+
+// reworkReport return the rate of reopened completions and the mean count of
+// open tickets at the reopened and at the final completions
+func (sim simulation) reworkReport() string {
+	places := sim.params.Precision
+	reworks := sim.reworkWip.n
+	completions := reworks + sim.doneWip.n
+	return fmt.Sprintf("Rework: %d of %d completions (%s), mean WIP at"+
+		" rework: %s at done: %s\n", reworks, completions,
+		orNA("%.1f%%", percentOf(reworks, completions), places),
+		orNA("%.2f", sim.reworkWip.mean(), places),
+		orNA("%.2f", sim.doneWip.mean(), places))
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// randSource the random values drawn by the sampler
+type randSource interface {
+	NormFloat64() float64
+	ExpFloat64() float64
+	Float64() float64
+}
+
+// loggingRand a random source writing each value drawn in order to w,
+// a line of sequence number, kind of draw and value
+type loggingRand struct {
+	rng randSource
+	w   io.Writer
+	n   int // count of values drawn
+}
+
+// newLoggingRand create a random source logging the draws of rng to w
+func newLoggingRand(rng randSource, w io.Writer) *loggingRand {
+	return &loggingRand{rng: rng, w: w}
+}
+
+// log write the draw and return the value
+func (l *loggingRand) log(kind string, v float64) float64 {
+	fmt.Fprintln(l.w, l.n, kind, v)
+	l.n++
+	return v
+}
+
+// NormFloat64 draw a normal distributed value and log it
+func (l *loggingRand) NormFloat64() float64 {
+	return l.log("norm", l.rng.NormFloat64())
+}
+
+// ExpFloat64 draw an exponential distributed value and log it
+func (l *loggingRand) ExpFloat64() float64 {
+	return l.log("exp", l.rng.ExpFloat64())
+}
+
+// Float64 draw a uniform value in [0, 1) and log it
+func (l *loggingRand) Float64() float64 {
+	return l.log("uniform", l.rng.Float64())
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"text/tabwriter"
+)
+
+// sampled a ticket of the sample by its seq, nil if rejected at intake
+type sampled struct {
+	seq int
+	t   *ticket
+}
+
+// reservoir a uniform random sample of at most n of the tickets added,
+// by reservoir sampling in a single pass while they arrive. The sampled
+// tickets are those of the simulation, which keeps all its tickets, so their
+// records are final after the run.
+type reservoir struct {
+	n     int       // size of the sample
+	seen  int       // count of tickets added
+	items []sampled // the sampled tickets
+	rng   *rand.Rand
+}
+
+// newReservoir create a reservoir for a sample of n
+func newReservoir(n int, rng *rand.Rand) *reservoir {
+	return &reservoir{n: n, items: make([]sampled, 0, n), rng: rng}
+}
+
+// add offer the ticket with seq to the sample, it replaces a sampled ticket
+// with probability n divided by the count of tickets added, t is nil for a
+// ticket rejected at intake. Expedite tickets, seq -1, are not sampled.
+func (r *reservoir) add(seq int, t *ticket) {
+	if seq < 0 {
+		return
+	}
+	r.seen++
+	if len(r.items) < r.n {
+		r.items = append(r.items, sampled{seq, t})
+		return
+	}
+	if j := r.rng.Intn(r.seen); j < r.n {
+		r.items[j] = sampled{seq, t}
+	}
+}
+
+// sample return the sampled tickets in order of seq
+func (r *reservoir) sample() []sampled {
+	s := make([]sampled, len(r.items))
+	copy(s, r.items)
+	sort.SliceStable(s, func(i, j int) bool {
+		return s[i].seq < s[j].seq
+	})
+	return s
+}
+
+// sampleTickets sample n of the tickets arriving while the simulations run,
+// each simulation offers every new ticket to its reservoir, also those it
+// rejects, seeded from the seed of the parameters, so all sample the same
+// tickets by seq
+func (simset simulationset) sampleTickets(n int) {
+	for i, s := range simset {
+		simset[i].sample = newReservoir(n,
+			rand.New(rand.NewSource(s.params.Seed+2)))
+	}
+}
+
+// sampleReport create the records of the sampled tickets per simulation in
+// the columns of the tickets of simulation.String by seq, followed by the
+// seq of the sampled tickets rejected at intake
+func sampleReport(simset simulationset) string {
+	var buf bytes.Buffer
+	for _, s := range simset {
+		if s.sample == nil {
+			continue
+		}
+		sample := s.sample.sample()
+		buf.WriteString(fmt.Sprintf("%s, sample of %d tickets\n", s.name,
+			len(sample)))
+		tw := tabwriter.NewWriter(&buf, 0, 0, 1, ' ', 0)
+		fmt.Fprintln(tw, "# seq\t"+ticketColumns)
+		var rejected []int
+		for _, st := range sample {
+			if st.t == nil {
+				rejected = append(rejected, st.seq)
+				continue
+			}
+			st.t.writeRow(tw, st.seq)
+		}
+		tw.Flush()
+		if len(rejected) > 0 {
+			buf.WriteString(fmt.Sprintln("Rejected at intake, seq:", rejected))
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+)
+
+// scenario the setup of an experiment read from a JSON file,
+// the parameters as in the manifest and the output format
+type scenario struct {
+	Params
+	Format string // the output format, empty for the default
+}
+
+// readScenario read the scenario from the JSON file into the parameters and
+// the format, the values missing in the file stay unchanged.
+// Unknown keys are an error.
+func readScenario(file string, p *Params, format *string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sc := scenario{Params: *p, Format: *format}
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&sc); err != nil {
+		return err
+	}
+	*p = sc.Params
+	*format = sc.Format
+	return nil
+}
+
+// presets the scenario presets by name, each sets the parameters of the
+// scenario consistently before the scenario file and the flags
+var presets = map[string]func(p *Params){
+	// bigbatch all tickets arrive at day 0, no further arrivals,
+	// the days to drain them are estimated
+	"bigbatch": func(p *Params) {
+		p.ArrivalModel = arrivalBatch
+		p.StarveDays = 0
+		p.Days = 0
+	},
+}
+
+// batchDays return the days to drain a batch of tickets, twice the mean
+// effort of the batch divided by the capacity
+func batchDays(p *Params) int {
+	effort := float64(p.BatchSize) * p.MeanEffortNew
+	return int(math.Ceil(2*effort/p.effectiveCapacity())) + 1
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// scoreMetrics the metrics of the score card by name, lower is better
+var scoreMetrics = map[string]func(simulation) float64{
+	"leadtime": func(s simulation) float64 {
+		m, _, _ := s.statsLeadTime()
+		return m
+	},
+	"p95": func(s simulation) float64 {
+		if len(s.leadtimes()) == 0 {
+			return math.NaN()
+		}
+		return float64(s.percentile(95))
+	},
+	"stability": func(s simulation) float64 {
+		return s.throughputCv()
+	},
+	"gini": func(s simulation) float64 {
+		return giniOf(s.leadtimes())
+	},
+}
+
+// scoreWeight the weight of a metric of the score card
+type scoreWeight struct {
+	metric string
+	weight float64
+}
+
+// parseWeights parse the comma separated metric:weight pairs of -weights
+func parseWeights(spec string) ([]scoreWeight, error) {
+	var weights []scoreWeight
+	for _, part := range strings.Split(spec, ",") {
+		name, w, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("%q not of form metric:weight", part)
+		}
+		if _, ok := scoreMetrics[name]; !ok {
+			return nil, fmt.Errorf("unknown metric %s, use leadtime, p95,"+
+				" stability or gini", name)
+		}
+		weight, err := strconv.ParseFloat(w, 64)
+		if err != nil {
+			return nil, err
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("weight of %s must not be negative", name)
+		}
+		weights = append(weights, scoreWeight{name, weight})
+	}
+	return weights, nil
+}
+
+// giniOf return the Gini coefficient of the values, 0 if all are equal, the
+// closer to 1 the more unequal, NaN without values or a sum of 0
+func giniOf(values []int) float64 {
+	sorted := make([]int, len(values))
+	copy(sorted, values)
+	sort.Ints(sorted)
+	n := len(sorted)
+	sum, weighted := 0, 0
+	for i, v := range sorted {
+		sum += v
+		weighted += (i + 1) * v
+	}
+	if sum == 0 {
+		return math.NaN()
+	}
+	return 2*float64(weighted)/(float64(n)*float64(sum)) -
+		float64(n+1)/float64(n)
+}
+
+// scores return the weighted score of each simulation, the weighted sum of
+// its metrics each normalized from 0 for the best to 1 for the worst of the
+// strategies, lower is better. A metric equal in all strategies adds 0, an
+// undefined one 1.
+func (simset simulationset) scores(weights []scoreWeight) []float64 {
+	scores := make([]float64, len(simset))
+	values := make([]float64, len(simset))
+	for _, w := range weights {
+		lowest, highest := math.Inf(1), math.Inf(-1)
+		for i, s := range simset {
+			values[i] = scoreMetrics[w.metric](s)
+			if math.IsNaN(values[i]) || math.IsInf(values[i], 0) {
+				continue
+			}
+			lowest = math.Min(lowest, values[i])
+			highest = math.Max(highest, values[i])
+		}
+		for i, v := range values {
+			switch {
+			case math.IsNaN(v) || math.IsInf(v, 0):
+				scores[i] += w.weight
+			case highest > lowest:
+				scores[i] += w.weight * (v - lowest) / (highest - lowest)
+			}
+		}
+	}
+	return scores
+}
+
+// scoreCard return the weighted score of each strategy and mark the best,
+// the one with the lowest score, empty if there are less than two strategies
+func (simset simulationset) scoreCard(weights []scoreWeight) string {
+	if len(simset) < 2 {
+		return ""
+	}
+	scores := simset.scores(weights)
+	best := lowestIndex(scores)
+	parts := make([]string, len(weights))
+	for i, w := range weights {
+		parts[i] = fmt.Sprintf("%s:%g", w.metric, w.weight)
+	}
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("Score card (%s), each metric normalized"+
+		" from 0 best to 1 worst\n# strategy: score\n", strings.Join(parts, ",")))
+	for i, s := range simset {
+		score := orNA("%.2f", scores[i], s.params.Precision)
+		if i == best {
+			if useColor {
+				score = colorBest + score + colorReset
+			}
+			score += " best"
+		}
+		buf.WriteString(fmt.Sprintf("%s: %s\n", s.name, score))
+	}
+	return buf.String()
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+)
+
+// influence the effect of a ticket on the mean leadtime of the other tickets
+type influence struct {
+	index int     // index of the ticket in order of arrival
+	t     *ticket // the ticket in the base simulation
+	delta float64 // mean leadtime of the others with minus without the ticket
+}
+
+// withoutTicket return a copy of the arrivals without the ticket with index
+// in order of arrival
+func withoutTicket(arrivals [][]*ticket, index int) [][]*ticket {
+	cp := make([][]*ticket, len(arrivals))
+	i := 0
+	for d, tickets := range arrivals {
+		if index >= i && index < i+len(tickets) {
+			day := make([]*ticket, 0, len(tickets)-1)
+			day = append(day, tickets[:index-i]...)
+			day = append(day, tickets[index-i+1:]...)
+			cp[d] = day
+		} else {
+			cp[d] = tickets
+		}
+		i += len(tickets)
+	}
+	return cp
+}
+
+// sumLeadTime return the sum of the leadtimes and the count of the tickets
+// of a simulation not cancelled
+func (sim simulation) sumLeadTime() (int, int) {
+	sum, n := 0, 0
+	for _, t := range sim.tickets {
+		if !t.cancelled {
+			sum += t.leadtime
+			n++
+		}
+	}
+	return sum, n
+}
+
+// sensitivity rerun all strategies once without each ticket of the arrivals
+// and compare the mean leadtime of the other tickets with the base simulations.
+// The ticket is matched in each strategy by its seq, a strategy that rejected
+// it at intake or cancelled it is skipped, expedite tickets are not removed.
+// Return per strategy a report of the top tickets whose removal shortens the
+// leadtime of the others most. If the context is cancelled return the error.
+func sensitivity(ctx context.Context, p *Params, arrivals [][]*ticket,
+	base simulationset, top int) (string, error) {
+	var all []*ticket
+	for _, tickets := range arrivals {
+		all = append(all, tickets...)
+	}
+	influences := make([][]influence, len(base))
+	for i := range base {
+		influences[i] = make([]influence, 0, len(all))
+	}
+	if len(all) < 2 {
+		return "Sensitivity needs at least 2 tickets\n", nil
+	}
+	for k, removed := range all {
+		if removed.expedite {
+			continue
+		}
+		simset, err := NewSimulationset(p).run(ctx, withoutTicket(arrivals, k))
+		if err = warnDrain("sensitivity", err); err != nil {
+			return "", err
+		}
+		for i, s := range simset {
+			t, ok := base[i].bySeq[removed.seq]
+			if !ok || t.cancelled {
+				continue
+			}
+			sum, n := base[i].sumLeadTime()
+			sumWithout, nWithout := s.sumLeadTime()
+			if n < 2 || nWithout == 0 {
+				continue
+			}
+			with := float64(sum-t.leadtime) / float64(n-1)
+			without := float64(sumWithout) / float64(nWithout)
+			influences[i] = append(influences[i], influence{k, t, with - without})
+		}
+	}
+	places := p.Precision
+	var buf bytes.Buffer
+	for i, s := range base {
+		inf := influences[i]
+		sort.SliceStable(inf, func(a, b int) bool {
+			return inf[a].delta > inf[b].delta
+		})
+		if len(inf) > top {
+			inf = inf[:top]
+		}
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString(fmt.Sprintf("Sensitivity %s\n", s.name))
+		buf.WriteString("# ticket startday effort leadtime delta-mean-leadtime-others\n")
+		for _, f := range inf {
+			buf.WriteString(fmt.Sprintf(withPrecision("%d %d %d %d %.2f\n",
+				places), f.index, f.t.startday, f.t.effort, f.t.leadtime, f.delta))
+		}
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,657 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// meanOf return the mean of the values
+func meanOf(values []int) float64 {
+	sum := 0
+	for _, v := range values {
+		sum += v
+	}
+	return float64(sum) / float64(len(values))
+}
+
+// medianOf return the median of the values
+func medianOf(values []int) float64 {
+	sorted := make([]int, len(values))
+	copy(sorted, values)
+	sort.Ints(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return float64(sorted[n/2])
+	}
+	return float64(sorted[n/2-1]+sorted[n/2]) / 2
+}
+
+// welford the running mean and variance of values by Welford's algorithm,
+// stable and single pass
+type welford struct {
+	n  int
+	mu float64 // mean of the values added
+	m2 float64 // sum of the squared differences from the mean
+}
+
+// add add the value
+func (w *welford) add(x float64) {
+	w.n++
+	d := x - w.mu
+	w.mu += d / float64(w.n)
+	w.m2 += d * (x - w.mu)
+}
+
+// mean return the mean of the values, NaN if there are none
+func (w *welford) mean() float64 {
+	if w.n == 0 {
+		return math.NaN()
+	}
+	return w.mu
+}
+
+// stdev return the population standard deviation of the values,
+// NaN if there are none
+func (w *welford) stdev() float64 {
+	if w.n == 0 {
+		return math.NaN()
+	}
+	return math.Sqrt(w.m2 / float64(w.n))
+}
+
+// doneLeadtimes return the leadtimes of the done tickets
+func (sim simulation) doneLeadtimes() []int {
+	lts := make([]int, 0, len(sim.tickets))
+	for _, t := range sim.tickets {
+		if t.effort > 0 && t.done(sim.lastday) {
+			lts = append(lts, t.leadtime)
+		}
+	}
+	return lts
+}
+
+// bootstrapCI return the 1-alpha confidence interval of the statistic over
+// the leadtimes of the done tickets from n resamples with replacement.
+// The resampling is seeded from the seed of the parameters.
+// Return NaN if there are no done tickets.
+func (sim simulation) bootstrapCI(stat func([]int) float64, n int,
+	alpha float64) (lo, hi float64) {
+	lts := sim.doneLeadtimes()
+	if len(lts) == 0 || n < 1 {
+		return math.NaN(), math.NaN()
+	}
+	rng := rand.New(rand.NewSource(sim.params.Seed))
+	stats := make([]float64, n)
+	resample := make([]int, len(lts))
+	for i := range stats {
+		for j := range resample {
+			resample[j] = lts[rng.Intn(len(lts))]
+		}
+		stats[i] = stat(resample)
+	}
+	sort.Float64s(stats)
+	lower := int(math.Floor(alpha / 2 * float64(n)))
+	upper := int(math.Ceil((1-alpha/2)*float64(n))) - 1
+	if upper < lower {
+		upper = lower
+	}
+	return stats[lower], stats[upper]
+}
+
+// bootstrapReport create the report of the 95% confidence intervals of mean
+// and median leadtime of the done tickets from n resamples
+func (sim simulation) bootstrapReport(n int) string {
+	if len(sim.doneLeadtimes()) == 0 {
+		return "Bootstrap 95% CI of done tickets leadtime: " + notAvailable + "\n"
+	}
+	alpha := 0.05
+	mlo, mhi := sim.bootstrapCI(meanOf, n, alpha)
+	dlo, dhi := sim.bootstrapCI(medianOf, n, alpha)
+	frmt := withPrecision("Bootstrap 95%% CI of done tickets leadtime"+
+		" mean: [%.2f, %.2f] median: [%.2f, %.2f]\n", sim.params.Precision)
+	return fmt.Sprintf(frmt, mlo, mhi, dlo, dhi)
+}
+
+// meanWorkdays return the mean count of days the tickets worked on were
+// worked on, NaN if no ticket was worked on
+func (sim simulation) meanWorkdays() float64 {
+	days := make([]int, 0, len(sim.tickets))
+	for _, t := range sim.tickets {
+		if t.workdays > 0 {
+			days = append(days, t.workdays)
+		}
+	}
+	return meanOf(days)
+}
+
+// flowBreakdown return the mean days the done tickets were worked on and
+// waited in the queue, and the flow efficiency, the worked days divided by
+// the leadtime over all done tickets. Return NaN if there are no done tickets.
+func (sim simulation) flowBreakdown() (active, queue, efficiency float64) {
+	sumActive, sumLead, n := 0, 0, 0
+	for _, t := range sim.tickets {
+		if t.effort > 0 && t.done(sim.lastday) {
+			sumActive += t.workdays
+			sumLead += t.leadtime
+			n++
+		}
+	}
+	if n == 0 || sumLead == 0 {
+		return math.NaN(), math.NaN(), math.NaN()
+	}
+	active = float64(sumActive) / float64(n)
+	queue = float64(sumLead-sumActive) / float64(n)
+	return active, queue, float64(sumActive) / float64(sumLead)
+}
+
+// nearestRank return the value not exceeded by percent of the sorted values
+// by nearest rank, 0 if there are no values
+func nearestRank(sorted []int, percent float64) int {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := max(int(math.Ceil(percent/100*float64(len(sorted)))), 1)
+	return sorted[rank-1]
+}
+
+// startDelays return the sorted days the done tickets waited for the first
+// work, with open also of the open tickets, those not worked yet until the
+// last day. Tickets without effort are not counted.
+func (sim simulation) startDelays(open bool) []int {
+	delays := make([]int, 0, len(sim.tickets))
+	for _, t := range sim.tickets {
+		if t.effort > 0 && !t.cancelled && (open || t.done(sim.lastday)) {
+			delays = append(delays, t.waitdays(sim.lastday))
+		}
+	}
+	sort.Ints(delays)
+	return delays
+}
+
+// startDelayReport create the report of the days the tickets waited for the
+// first work, the time to first touch, of the done tickets and with the open
+// ones
+func (sim simulation) startDelayReport() string {
+	parts := make([]string, 2)
+	for i, open := range []bool{false, true} {
+		delays := sim.startDelays(open)
+		if len(delays) == 0 {
+			parts[i] = notAvailable
+			continue
+		}
+		frmt := withPrecision("mean: %.2f 85%%: %d max: %d days",
+			sim.params.Precision)
+		parts[i] = fmt.Sprintf(frmt, meanOf(delays), nearestRank(delays, 85),
+			delays[len(delays)-1])
+	}
+	return fmt.Sprintf("Start delay of done tickets %s, with open tickets %s\n",
+		parts[0], parts[1])
+}
+
+// flowReport create the report of the leadtime breakdown of the done tickets
+func (sim simulation) flowReport() string {
+	active, queue, efficiency := sim.flowBreakdown()
+	frmt := withPrecision("Flow of done tickets active: %.2f days"+
+		" queue: %.2f days efficiency: %.1f%%\n", sim.params.Precision)
+	return fmt.Sprintf(frmt, active, queue, efficiency*100)
+}
+
+// effortBuckets the upper bounds of the effort buckets in h, the last bucket
+// is open
+var effortBuckets = []int{2, 4, 8}
+
+// effortBucket return the index of the effort bucket of effort
+func effortBucket(effort int) int {
+	for i, upper := range effortBuckets {
+		if effort <= upper {
+			return i
+		}
+	}
+	return len(effortBuckets)
+}
+
+// bucketName return the name of the effort bucket i
+func bucketName(i int) string {
+	if i < len(effortBuckets) {
+		return fmt.Sprintf("<=%dh", effortBuckets[i])
+	}
+	return fmt.Sprintf(">%dh", effortBuckets[len(effortBuckets)-1])
+}
+
+// fastPathReport create the report of the percentage of the tickets done on
+// the day of arrival, overall and per effort bucket, and of the tickets done
+// within a day
+func (sim simulation) fastPathReport() string {
+	places := sim.params.Precision
+	n := len(effortBuckets) + 1
+	counts := make([]int, n)
+	sameDay := make([]int, n)
+	total, same, withinDay := 0, 0, 0
+	for _, t := range sim.tickets {
+		if t.effort == 0 || t.cancelled {
+			continue
+		}
+		b := effortBucket(t.effort)
+		counts[b]++
+		total++
+		if !t.done(sim.lastday) {
+			continue
+		}
+		if t.endday == t.startday {
+			sameDay[b]++
+			same++
+		}
+		if t.endday <= t.startday+1 {
+			withinDay++
+		}
+	}
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("Done on arrival day: %s, within a day:"+
+		" %s, by effort", orNA("%.1f%%", percentOf(same, total), places),
+		orNA("%.1f%%", percentOf(withinDay, total), places)))
+	for b := range counts {
+		buf.WriteString(fmt.Sprintf(" %s: %s", bucketName(b),
+			orNA("%.1f%%", percentOf(sameDay[b], counts[b]), places)))
+	}
+	buf.WriteString("\n")
+	return buf.String()
+}
+
+// percentOf return part in percent of total, NaN if total is 0
+func percentOf(part, total int) float64 {
+	return 100 * float64(part) / float64(total)
+}
+
+// sumCompletionTimes return the count of the done tickets, the sum of their
+// leadtimes and the sum of their completion times, the days from day 0 to
+// the end of the day they are done
+func (sim simulation) sumCompletionTimes() (int, int, int) {
+	done, sumLead, sumCompletion := 0, 0, 0
+	for _, t := range sim.tickets {
+		if t.effort > 0 && t.done(sim.lastday) {
+			done++
+			sumLead += t.leadtime
+			sumCompletion += t.endday + 1
+		}
+	}
+	return done, sumLead, sumCompletion
+}
+
+// weightedCompletion return the sum of the completion times of the done
+// tickets, the day after the day done, weighted by their cost of delay, the
+// objective of Smith's rule
+func (sim simulation) weightedCompletion() int {
+	sum := 0
+	for _, t := range sim.tickets {
+		if t.effort > 0 && t.done(sim.lastday) {
+			sum += t.costofdelay * (t.endday + 1)
+		}
+	}
+	return sum
+}
+
+// steadyWindow the days of the rolling mean of the leadtime and of the
+// following days it must stay within steadyThreshold of it
+const steadyWindow = 20
+
+// steadyThreshold the relative change of the rolling mean of the leadtime
+// counted as stable
+const steadyThreshold = 0.2
+
+// rollingLeadtime return per day the mean leadtime of the tickets done in
+// the window days up to the day, NaN if none is done
+func (sim simulation) rollingLeadtime(window int) []float64 {
+	sum := make([]int, sim.lastday+1)
+	count := make([]int, sim.lastday+1)
+	for _, t := range sim.tickets {
+		if t.effort > 0 && t.done(sim.lastday) {
+			sum[t.endday] += t.leadtime
+			count[t.endday]++
+		}
+	}
+	rolling := make([]float64, sim.lastday+1)
+	s, c := 0, 0
+	for d := range rolling {
+		s, c = s+sum[d], c+count[d]
+		if d >= window {
+			s, c = s-sum[d-window], c-count[d-window]
+		}
+		rolling[d] = float64(s) / float64(c)
+		if c == 0 {
+			rolling[d] = math.NaN()
+		}
+	}
+	return rolling
+}
+
+// steadyState return the first day the rolling mean of the leadtime over
+// steadyWindow days stays within steadyThreshold for the next steadyWindow
+// days, the time to steady state, -1 if it is not reached
+func (sim simulation) steadyState() int {
+	rolling := sim.rollingLeadtime(steadyWindow)
+	for d := steadyWindow - 1; d+steadyWindow < len(rolling); d++ {
+		stable := !math.IsNaN(rolling[d])
+		for k := 1; stable && k <= steadyWindow; k++ {
+			stable = math.Abs(rolling[d+k]-rolling[d]) <=
+				steadyThreshold*rolling[d]
+		}
+		if stable {
+			return d
+		}
+	}
+	return -1
+}
+
+// steadyReport return the time to steady state as a line
+func (sim simulation) steadyReport() string {
+	day := sim.steadyState()
+	if day < 0 {
+		return "Steady state: not reached\n"
+	}
+	return fmt.Sprintf("Steady state from day: %d\n", day)
+}
+
+// capacityChangeReport return the mean leadtime of the done tickets created
+// before and from the day of the capacity change and the mean backlog in
+// hours of the days before and from it, n/a for a mean without days if the
+// run ends before the change
+func (sim simulation) capacityChangeReport() string {
+	places := sim.params.Precision
+	change := sim.params.CapacityDay
+	var before, after []int
+	for _, t := range sim.tickets {
+		if t.effort > 0 && t.done(sim.lastday) {
+			if t.startday < change {
+				before = append(before, t.leadtime)
+			} else {
+				after = append(after, t.leadtime)
+			}
+		}
+	}
+	backlog := sim.backlogHours()
+	split := min(change, len(backlog))
+	frmt := withPrecision("Capacity change on day %d to %.1f h: leadtime mean"+
+		" before %s after %s, backlog mean before %s h after %s h\n", places)
+	return fmt.Sprintf(frmt, change, sim.params.CapacityAfter,
+		orNA("%.2f", meanOf(before), places),
+		orNA("%.2f", meanOf(after), places),
+		orNA("%.1f", meanOf(backlog[:split]), places),
+		orNA("%.1f", meanOf(backlog[split:]), places))
+}
+
+// wipHistogram return a table of the percent of days with each count of
+// open tickets, a row per count and a column per strategy
+func (simset simulationset) wipHistogram() string {
+	counts := make([][]int, len(simset))
+	levels := 0
+	for i, s := range simset {
+		for _, wip := range s.wipPerDay() {
+			for len(counts[i]) <= wip {
+				counts[i] = append(counts[i], 0)
+			}
+			counts[i][wip]++
+		}
+		levels = max(levels, len(counts[i]))
+	}
+	var buf bytes.Buffer
+	buf.WriteString("Percent of days by open tickets\n# wip")
+	for _, s := range simset {
+		buf.WriteString(" " + s.id)
+	}
+	buf.WriteString("\n")
+	for wip := 0; wip < levels; wip++ {
+		buf.WriteString(fmt.Sprint(wip))
+		for i, s := range simset {
+			n := 0
+			if wip < len(counts[i]) {
+				n = counts[i][wip]
+			}
+			buf.WriteString(fmt.Sprintf(withPrecision(" %.1f",
+				s.params.Precision), percentOf(n, s.lastday+1)))
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+// ageBuckets the upper bounds in days of the age buckets of open tickets
+var ageBuckets = []int{1, 5, 20}
+
+// openAgingReport return the count of tickets open at the last day, the mean
+// and maximal age in days since their start day and the count per age bucket
+func (sim simulation) openAgingReport() string {
+	ages := make([]int, 0)
+	for _, t := range sim.tickets {
+		if t.effort > 0 && !t.cancelled && !t.done(sim.lastday) {
+			ages = append(ages, sim.lastday-t.startday)
+		}
+	}
+	if len(ages) == 0 {
+		return "Open tickets at end: 0\n"
+	}
+	counts := make([]int, len(ageBuckets)+1)
+	oldest := 0
+	for _, age := range ages {
+		oldest = max(oldest, age)
+		i := sort.SearchInts(ageBuckets, age)
+		counts[i]++
+	}
+	var buf bytes.Buffer
+	frmt := withPrecision("Open tickets at end: %d, age mean: %.2f"+
+		" max: %d days, by age", sim.params.Precision)
+	buf.WriteString(fmt.Sprintf(frmt, len(ages), meanOf(ages), oldest))
+	for i, b := range ageBuckets {
+		buf.WriteString(fmt.Sprintf(" <=%dd: %d", b, counts[i]))
+	}
+	buf.WriteString(fmt.Sprintf(" >%dd: %d\n", ageBuckets[len(ageBuckets)-1],
+		counts[len(ageBuckets)]))
+	return buf.String()
+}
+
+// eligibleWork return the remaining work of the tickets open at the end of
+// day that could have been worked on the day, not blocked by a prerequisite
+// open at the end of the day and within the workable part on the arrival day
+func (sim simulation) eligibleWork(day int) int {
+	work := 0
+	for _, t := range sim.tickets {
+		if t.startday > day || t.remaining[day+1] == 0 {
+			continue
+		}
+		blocked := false
+		for _, pre := range t.deps {
+			blocked = blocked || pre.remaining[day+1] > 0
+		}
+		if blocked {
+			continue
+		}
+		r := t.remaining[day+1]
+		if day == t.startday && sim.params.ArrivalTime < 1 {
+			workable := int(math.Round(sim.params.ArrivalTime * float64(t.effort)))
+			r = min(r, max(workable-(t.effort-r), 0))
+		}
+		work += r
+	}
+	return work
+}
+
+// policyIdle return the count of days with hours not worked on tickets while
+// eligible work was open and the total of these hours, the idleness caused by
+// the strategy not by missing work. A work-conserving strategy has none.
+func (sim simulation) policyIdle() (int, int) {
+	days, hours := 0, 0
+	// the last day of the horizon is not burned down
+	for d := 0; d <= sim.lastday && d+1 < sim.params.horizon(); d++ {
+		h := sim.hoursOfDay(d) - sim.worked[d]
+		if h <= 0 {
+			continue
+		}
+		if h = min(h, sim.eligibleWork(d)); h > 0 {
+			days++
+			hours += h
+		}
+	}
+	return days, hours
+}
+
+// notAvailable the text of a metric undefined without done tickets
+const notAvailable = "n/a (0 completed)"
+
+// meanOrNA format the mean of the values by frmt with the decimal places,
+// notAvailable if there are none
+func meanOrNA(frmt string, values []int, places int) string {
+	if len(values) == 0 {
+		return notAvailable
+	}
+	return orNA(frmt, meanOf(values), places)
+}
+
+// precisionVerbs the verbs of the metrics in the formats, %.2f and %.1f,
+// also signed and with a width
+var precisionVerbs = regexp.MustCompile(`%(\+?[0-9]*)\.([12])f`)
+
+// withPrecision the format with the decimal places of its %.2f verbs set to
+// places and of its %.1f verbs, the percentages, to one less, at least 0
+func withPrecision(frmt string, places int) string {
+	if places == 2 {
+		return frmt
+	}
+	return precisionVerbs.ReplaceAllStringFunc(frmt, func(verb string) string {
+		m := precisionVerbs.FindStringSubmatch(verb)
+		p := places
+		if m[2] == "1" {
+			p = max(places-1, 0)
+		}
+		return "%" + m[1] + "." + strconv.Itoa(p) + "f"
+	})
+}
+
+// orNA format the value by frmt with the decimal places, "n/a" if it is NaN
+// or infinite
+func orNA(frmt string, v float64, places int) string {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return "n/a"
+	}
+	return fmt.Sprintf(withPrecision(frmt, places), v)
+}
+
+// sortKeys the metrics to sort the simulations by, best first, lower is
+// better, NaN last
+var sortKeys = map[string]func(simulation) float64{
+	"mean": func(s simulation) float64 {
+		m, _, _ := s.statsLeadTime()
+		return m
+	},
+	"p95": func(s simulation) float64 {
+		if len(s.leadtimes()) == 0 {
+			return math.NaN()
+		}
+		return float64(s.percentile(95))
+	},
+	"throughput": func(s simulation) float64 {
+		return -s.throughput()
+	},
+}
+
+// sortedBy return a copy of the simulations sorted by the metric of
+// sortKeys best first, equal ones in the order of the set
+func (simset simulationset) sortedBy(metric string) simulationset {
+	key := sortKeys[metric]
+	keys := make(map[string]float64, len(simset))
+	for _, s := range simset {
+		keys[s.name] = key(s)
+	}
+	sorted := append(simulationset(nil), simset...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ki, kj := keys[sorted[i].name], keys[sorted[j].name]
+		if math.IsNaN(kj) {
+			return !math.IsNaN(ki)
+		}
+		return ki < kj
+	})
+	return sorted
+}
+
+// flowDebt return the sum over the days of the remaining work of the tickets
+// open and not blocked at the start of the day but not worked on the day,
+// the work deferred by the strategy
+func (sim simulation) flowDebt() int {
+	debt := 0
+	// the last day of the horizon is not burned down
+	for d := 0; d <= sim.lastday && d+1 < sim.params.horizon(); d++ {
+		for _, t := range sim.tickets {
+			if t.startday > d || t.remaining[d] == 0 ||
+				t.remaining[d+1] != t.remaining[d] {
+				continue
+			}
+			blocked := false
+			for _, pre := range t.deps {
+				blocked = blocked || pre.remaining[d] > 0
+			}
+			if !blocked {
+				debt += t.remaining[d]
+			}
+		}
+	}
+	return debt
+}
+
+// effortLeadtimeCorrelation return the Pearson correlation coefficient of
+// the effort and the leadtime of the done tickets, near 1 if large tickets
+// wait longer. NaN with less than two done tickets or without variation.
+func (sim simulation) effortLeadtimeCorrelation() float64 {
+	var efforts, leadtimes []float64
+	for _, t := range sim.tickets {
+		if t.effort > 0 && t.done(sim.lastday) {
+			efforts = append(efforts, float64(t.effort))
+			leadtimes = append(leadtimes, float64(t.leadtime))
+		}
+	}
+	n := float64(len(efforts))
+	if n < 2 {
+		return math.NaN()
+	}
+	sumE, sumL := 0.0, 0.0
+	for i := range efforts {
+		sumE += efforts[i]
+		sumL += leadtimes[i]
+	}
+	meanE, meanL := sumE/n, sumL/n
+	cov, varE, varL := 0.0, 0.0, 0.0
+	for i := range efforts {
+		de, dl := efforts[i]-meanE, leadtimes[i]-meanL
+		cov += de * dl
+		varE += de * de
+		varL += dl * dl
+	}
+	return cov / math.Sqrt(varE*varL)
+}
+
+// mm1Report return the mean leadtime of oldest first against the M/M/1 mean
+// time in system W = 1/(mu-lambda) for Poisson arrivals and exponential
+// efforts, lambda the tickets per day and mu the tickets per day the
+// capacity serves. Empty for other models, without oldest first or if the
+// offered load is not below 1. The leadtime counts the day of arrival, the
+// efforts are whole hours, expect a difference of about a day.
+func (simset simulationset) mm1Report() string {
+	oldest, ok := simset.find("oldest")
+	p := oldest.params
+	if !ok || p.ArrivalModel != arrivalInterarrival ||
+		p.EffortModel != effortExponential {
+		return ""
+	}
+	lambda := p.MeanNewPerDay
+	mu := p.effectiveCapacity() / p.MeanEffortNew
+	if lambda >= mu {
+		return ""
+	}
+	m, _, _ := oldest.statsLeadTime()
+	frmt := withPrecision("M/M/1 mean time in system (lambda %.2f,"+
+		" mu %.2f per day): %.2f days, simulated %s: %s days\n", p.Precision)
+	return fmt.Sprintf(frmt, lambda, mu, 1/(mu-lambda),
+		oldest.name, orNA("%.2f", m, p.Precision))
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+)
+
+// the routing rules of new tickets to teams
+const (
+	routeRoundRobin  = "round-robin"
+	routeLeastLoaded = "least-loaded"
+	routeRandom      = "random"
+)
+
+// backlog return the remaining work of the open tickets during day
+func (sim *simulation) backlog(day int) int {
+	sum := 0
+	for _, t := range sim.tickets {
+		sum += t.current(day)
+	}
+	return sum
+}
+
+// route return the index of the team for the n-th new ticket at day
+func route(teams simulationset, routing string, n, day int,
+	rng *rand.Rand) int {
+	switch routing {
+	case routeLeastLoaded:
+		best, load := 0, 0
+		for i := range teams {
+			if l := teams[i].backlog(day); i == 0 || l < load {
+				best, load = i, l
+			}
+		}
+		return best
+	case routeRandom:
+		return rng.Intn(len(teams))
+	default:
+		return n % len(teams)
+	}
+}
+
+// runTeams simulate the strategy with p.Teams teams, each with the capacity
+// of the parameters, the new tickets routed to the teams by p.Routing.
+// The teams run like the strategies in run, see runRouted for the errors.
+func runTeams(ctx context.Context, p *Params, st strategy,
+	arrivals [][]*ticket) (simulationset, error) {
+	sz := p.Days * 3 / 2 / p.Teams // some more size avoid reallocation
+	teams := make(simulationset, p.Teams)
+	for i := range teams {
+		teams[i] = NewSimulation(st, p, sz)
+		teams[i].name = fmt.Sprint(st.name, ", team ", i+1)
+	}
+	// the routing is seeded from the seed of the parameters
+	rng := rand.New(rand.NewSource(p.Seed + 5))
+	n := 0
+	return teams.runRouted(ctx, arrivals,
+		func(teams simulationset, day int, tickets []*ticket) simulationset {
+			for _, t := range tickets {
+				i := route(teams, p.Routing, n, day, rng)
+				teams[i] = teams[i].addTickets([]*ticket{t})
+				n++
+			}
+			return teams
+		})
+}
+
+// teamsReport simulate each strategy with p.Teams teams and return the mean
+// leadtime per team and of all tickets of the teams per strategy.
+// If the context is cancelled return the error.
+func teamsReport(ctx context.Context, p *Params,
+	arrivals [][]*ticket) (string, error) {
+	places := p.Precision
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("Teams: %d, routing: %s\n", p.Teams, p.Routing))
+	buf.WriteString("# strategy mean-leadtime [mean leadtime per team]\n")
+	for _, st := range strategies {
+		if !p.runs(st.id) {
+			continue
+		}
+		teams, err := runTeams(ctx, p, st, arrivals)
+		if err = warnDrain("teams "+st.id, err); err != nil {
+			return "", err
+		}
+		all := make([]int, 0, p.Days*3/2)
+		means := make([]string, len(teams))
+		for i, team := range teams {
+			lts := team.leadtimes()
+			all = append(all, lts...)
+			means[i] = meanOrNA("%.2f", lts, places)
+		}
+		buf.WriteString(fmt.Sprintf("%s: %s %v\n", st.name,
+			meanOrNA("%.2f", all, places), means))
+	}
+	return buf.String(), nil
+}
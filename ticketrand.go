@@ -0,0 +1,31 @@
+package main
+
+import "math"
+
+// the offsets of the seed of the random streams drawn per ticket
+const (
+	streamRework    = 8  // rolls to reopen a done ticket
+	streamDiscovery = 10 // misestimates of the effort
+	streamCancel    = 11 // rolls to cancel an open ticket
+)
+
+// ticketDraw return the n-th uniform draw in [0, 1) of the stream of the
+// ticket, a hash of the seed, the stream, the sequence and start day of the
+// ticket and n. The draws of a ticket are the same in all simulations and
+// independent of the order of the draws for the other tickets and of the
+// other features enabled.
+func ticketDraw(seed int64, stream int, t *ticket, n int) float64 {
+	h := splitmix(uint64(seed + int64(stream)))
+	h = splitmix(h ^ uint64(t.seq))
+	h = splitmix(h ^ uint64(t.startday))
+	h = splitmix(h ^ uint64(n))
+	return float64(h>>11) / (1 << 53)
+}
+
+// ticketNorm return the n-th standard normal draw of the stream of the
+// ticket, the Box-Muller transform of two draws of ticketDraw
+func ticketNorm(seed int64, stream int, t *ticket, n int) float64 {
+	u1 := ticketDraw(seed, stream, t, 2*n)
+	u2 := ticketDraw(seed, stream, t, 2*n+1)
+	return math.Sqrt(-2*math.Log(1-u1)) * math.Cos(2*math.Pi*u2)
+}
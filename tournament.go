@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+)
+
+// tournament run the strategies on the arrivals of seeds new seeds derived
+// from the seed of the parameters and rank them per seed by mean leadtime.
+// Return a leaderboard of the win rate, the share of seeds a strategy had
+// the lowest mean leadtime, ties won by all tied, and the average rank,
+// best first. If the context is cancelled return the error.
+func tournament(ctx context.Context, p *Params, seeds int) (string, error) {
+	var names []string
+	wins := make(map[string]int)
+	ranks := make(map[string]int)
+	for i := 0; i < seeds; i++ {
+		pr := *p
+		pr.Seed = p.Seed + 1000 + int64(i)
+		smp := newSampler(rand.New(rand.NewSource(pr.Seed)), &pr)
+		arrivals, _, _ := createArrivals(&pr, smp, io.Discard)
+		simset, err := Run(ctx, &pr, arrivals)
+		err = warnDrain(fmt.Sprint("tournament seed ", pr.Seed), err)
+		if err != nil {
+			return "", err
+		}
+		means := make([]float64, len(simset))
+		for j, s := range simset {
+			means[j], _, _ = s.statsLeadTime()
+			if i == 0 {
+				names = append(names, s.name)
+			}
+		}
+		for j, s := range simset {
+			rank := 1
+			for _, m := range means {
+				if m < means[j] {
+					rank++
+				}
+			}
+			ranks[s.name] += rank
+			if rank == 1 {
+				wins[s.name]++
+			}
+		}
+	}
+	sort.SliceStable(names, func(a, b int) bool {
+		if wins[names[a]] != wins[names[b]] {
+			return wins[names[a]] > wins[names[b]]
+		}
+		return ranks[names[a]] < ranks[names[b]]
+	})
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("Leaderboard by mean leadtime over %d seeds\n",
+		seeds))
+	buf.WriteString("# strategy: win rate, average rank\n")
+	frmt := withPrecision("%s: %.0f%%, %.2f\n", p.Precision)
+	for _, name := range names {
+		buf.WriteString(fmt.Sprintf(frmt, name, percentOf(wins[name], seeds),
+			float64(ranks[name])/float64(seeds)))
+	}
+	return buf.String(), nil
+}
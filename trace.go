@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// allocation the hours of a day spent on a ticket
+type allocation struct {
+	day    int
+	ticket int // index of the ticket in the simulation
+	hours  int
+}
+
+// record record the allocation of hours to the ticket on day if tracing
+func (sim *simulation) record(t *ticket, day, hours int) {
+	if !sim.params.Trace || hours <= 0 {
+		return
+	}
+	sim.trace = append(sim.trace, allocation{day, t.id, hours})
+}
+
+// Trace return the allocations of hours to tickets in order of the work,
+// empty if not tracing
+func (sim simulation) Trace() []allocation {
+	return sim.trace
+}
+
+// traceReport create the trace of the simulation, a line per allocation
+func (sim simulation) traceReport() string {
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("Trace of %s\n", sim.name))
+	buf.WriteString("# day ticket hours\n")
+	for _, a := range sim.trace {
+		buf.WriteString(fmt.Sprintln(a.day, a.ticket, a.hours))
+	}
+	return buf.String()
+}
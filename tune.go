@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// tunePercentile the percentile of the leadtime minimized by the WIP tuning
+const tunePercentile = 85
+
+// wipTune search the WIP limit from lowest to highest minimizing the 85%
+// leadtime of the pull strategy on the arrivals by golden section search
+// over the integers, the leadtime is assumed unimodal in the WIP limit.
+// Return the WIP limit and its 85% leadtime, the lowest WIP limit on ties.
+// If the context is cancelled return the error.
+func wipTune(ctx context.Context, p *Params, arrivals [][]*ticket,
+	lowest, highest int) (int, int, error) {
+	sz := p.Days * 3 / 2 // some more size avoid reallocation
+	cache := make(map[int]int)
+	eval := func(w int) (int, error) {
+		if v, ok := cache[w]; ok {
+			return v, nil
+		}
+		pw := *p
+		pw.WipLimit = w
+		st, _ := findStrategy("pull")
+		simset, err := simulationset{NewSimulation(st, &pw, sz)}.run(ctx, arrivals)
+		if err = warnDrain("wip-tune", err); err != nil {
+			return 0, err
+		}
+		cache[w] = simset[0].percentile(tunePercentile)
+		return cache[w], nil
+	}
+	const invPhi = 0.6180339887498949
+	lo, hi := lowest, highest
+	for hi-lo > 2 {
+		a := hi - int(invPhi*float64(hi-lo)+0.5)
+		b := lo + int(invPhi*float64(hi-lo)+0.5)
+		if a >= b {
+			a, b = lo+(hi-lo)/2, lo+(hi-lo)/2+1
+		}
+		va, err := eval(a)
+		if err != nil {
+			return 0, 0, err
+		}
+		vb, err := eval(b)
+		if err != nil {
+			return 0, 0, err
+		}
+		if va <= vb {
+			hi = b
+		} else {
+			lo = a
+		}
+	}
+	best, bestV := 0, 0
+	for w := lo; w <= hi; w++ {
+		v, err := eval(w)
+		if err != nil {
+			return 0, 0, err
+		}
+		if w == lo || v < bestV {
+			best, bestV = w, v
+		}
+	}
+	return best, bestV, nil
+}
+
+// tuneReport create the report of the recommended WIP limit
+func tuneReport(wip, leadtime int) string {
+	return fmt.Sprintf("Recommended WIP limit of pull oldest first: %d,"+
+		" %d%% leadtime: %d days\n", wip, tunePercentile, leadtime)
+}
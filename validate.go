@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Validate check the parameters and their constraints on each other,
+// return all violations joined, nil if the parameters are valid
+func (p *Params) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, a ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, a...))
+		}
+	}
+	check(p.Days >= 1, "days must be at least 1")
+	check(p.MeanNewPerDay > 0 || p.ArrivalModel == arrivalBatch,
+		"mean new tickets per day must be positive")
+	check(p.StddevNewPerDay >= 0 && p.StddevEffortNew >= 0 &&
+		p.StddevCostDelay >= 0, "standard deviations must not be negative")
+	check(p.MinEffort >= 0, "min effort must not be negative")
+	switch p.ArrivalModel {
+	case arrivalDaily, arrivalInterarrival:
+	case arrivalBatch:
+		check(p.BatchSize >= 1, "batch-size must be at least 1")
+	default:
+		check(false, "arrival-model must be daily, interarrival or batch")
+	}
+	check(p.EffortModel == effortNormal || p.EffortModel == effortExponential,
+		"effort-model must be normal or exponential")
+	_, ok := policies[p.PullPolicy]
+	check(ok, "pull-policy must be fifo, lifo, sjf or edf")
+	_, ok = policies[p.WorkPolicy]
+	check(ok || p.WorkPolicy == policyEqual,
+		"work-policy must be fifo, lifo, sjf, edf or equal")
+	for _, id := range p.Strategies {
+		_, ok := findStrategy(id)
+		check(ok, "strategies: unknown strategy %s", id)
+	}
+	switch p.Rounding {
+	case roundHalfAway, roundFloor, roundCeil, roundStochastic:
+	default:
+		check(false, "rounding must be round, floor, ceil or stochastic")
+	}
+	check(p.SlaDays >= 0 && p.SlaPercent > 0 && p.SlaPercent <= 100,
+		"sla must not be negative, sla-percent in (0, 100]")
+	check(p.SlaDays <= p.Days, "sla must not exceed the days")
+	check(p.Bootstrap >= 0, "bootstrap must not be negative")
+	check(p.Precision >= 0, "precision must not be negative")
+	check(p.Capacity > 0, "capacity must be positive")
+	check(p.Overhead >= 0 && p.Overhead < p.Capacity,
+		"overhead must be at least 0 and less than the capacity")
+	check(p.CapacityDay >= 0 && p.CapacityDay < p.Days,
+		"capacity-change day must be at least 0 and less than the days")
+	check(p.CapacityDay == 0 || p.CapacityAfter > p.Overhead,
+		"capacity-change hours must exceed the overhead")
+	check(p.Rework >= 0 && p.Rework <= 1, "rework must be in [0, 1]")
+	check(p.ReworkWip >= 0, "rework-wip-coefficient must not be negative")
+	for _, e := range p.EffortQuantize {
+		check(e >= 1, "effort-quantize: effort %d must be at least 1", e)
+	}
+	check(p.SmallFraction >= 0 && p.SmallFraction <= 1,
+		"small-capacity-fraction must be in [0, 1]")
+	check(p.SmallThreshold >= p.MinEffort,
+		"small-threshold must be at least the min effort")
+	_, ok = tiebreaks[p.Tiebreak]
+	check(ok, "tiebreak must be fifo, lifo, random or smallest")
+	check(p.Teams >= 1, "teams must be at least 1")
+	switch p.Routing {
+	case routeRoundRobin, routeLeastLoaded, routeRandom:
+	default:
+		check(false, "routing must be round-robin, least-loaded or random")
+	}
+	check(p.Dependencies >= 0 && p.Dependencies <= 1,
+		"dependencies must be in [0, 1]")
+	check(p.MaxTickets >= 0, "max-tickets must not be negative")
+	check(p.ArrivalTime >= 0 && p.ArrivalTime <= 1,
+		"arrival-time must be in [0, 1]")
+	check(p.IntakeFeedback >= 0, "intake-feedback must not be negative")
+	check(p.Resolution >= 1, "resolution must be at least 1")
+	check(p.LearningRate >= 0, "learning-rate must not be negative")
+	check(p.WipLimit >= 1, "wip must be at least 1")
+	check(p.DailyCap >= 1, "daily-cap must be at least 1")
+	check(p.WipHours >= 1, "wip-hours must be at least 1")
+	check(p.RampCost >= 0, "ramp-cost must not be negative")
+	check(p.MaxWait >= 0, "max-wait must not be negative")
+	check(p.Conwip >= 1, "conwip must be at least 1")
+	check(p.EffortDiscovery >= 0, "effort-discovery must not be negative")
+	check(p.DrainDays >= 0, "drain-days must not be negative")
+	check(p.CancelRate >= 0 && p.CancelRate <= 1,
+		"cancel-rate must be in [0, 1]")
+	check(p.Parallel >= 0 && p.Parallel <= 1, "parallel must be in [0, 1]")
+	check(p.AwsjfRemaining >= 0 && p.AwsjfAge >= 0,
+		"awsjf-remaining and awsjf-age must not be negative")
+	for _, pattern := range p.Availability {
+		check(len(pattern) == 1 || len(pattern) == daysPerWeek,
+			"worker-availability must have 1 or %d fractions per worker",
+			daysPerWeek)
+		for _, f := range pattern {
+			check(f >= 0 && f <= 1,
+				"worker-availability fractions must be in [0, 1]")
+		}
+	}
+	check(p.ExpediteRate >= 0 && p.ExpediteRate <= 1,
+		"expedite-rate must be in [0, 1]")
+	check(p.ExpediteEffort >= 1, "expedite-effort must be at least 1")
+	check(p.DueFactor > 0, "due-factor must be positive")
+	return errors.Join(errs...)
+}
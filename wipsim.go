@@ -6,54 +6,322 @@
 // and standard deviation of 1 day.
 // Tickets have an effort in hours, with a gaussian distribution, with
 // mean 6 h and standard deviation of 4 h.
-// Troughput is fixed to 8 h per day
-// Five scheduling strategies are compared:
-// 1. Work on each ticket max 2h per day.
-// 2. Work on the tickets in order of arrival
-// 3. Work on the ticket with the shortest remaining work first
-// 4. Work on the yesterdays tickets first, then on shortest
-// 5. Divide remaining work by number of days open and work on ticket with
-//    smallest weight first
+// The team works 8 h per day by default.
+// All registered scheduling strategies run on the same arrivals and are
+// compared by the leadtime and further metrics of their tickets.
+//
+// The subcommands run, sweep, compare and forecast select an analysis mode,
+// list-strategies prints the strategies. The flags set the parameters of the
+// arrivals, the efforts, the team and the strategies and enable further
+// analyses and exports. wipsim -h prints the usage with all flags and
+// strategies.
 //
 // Ralf Poeppel 2021
 //
 package main
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"math"
 	"math/rand"
 	"os"
+	"os/signal"
 	"sort"
 	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
 )
 
 const maxPrint = 20 // when to print details
 
+// Params the parameters of a simulation run
+type Params struct {
+	Days            int         // number of days to simulate
+	Seed            int64       // seed of the random generator
+	MeanNewPerDay   float64     // mean count of new tickets per day
+	BatchSize       int         // count of tickets at day 0 for arrivalBatch
+	IntakeFeedback  float64     // reduction of the arrival rate per open ticket
+	Trace           bool        // record the hours per ticket and day
+	ArrivalTime     float64     // fraction of the effort workable on arrival day
+	MaxTickets      int         // maximum count of tickets created, 0 no limit
+	Dependencies    float64     // probability a ticket depends on an earlier one
+	Teams           int         // count of teams, each with the capacity
+	Routing         string      // routing of new tickets to the teams
+	Tiebreak        string      // order of tickets equal by a strategy
+	DailyCap        int         // hours per ticket and day of capped pull
+	WipHours        int         // maximum remaining hours in work to pull
+	ExpediteRate    float64     // probability of an expedite ticket per day
+	ExpediteEffort  int         // effort in h of an expedite ticket
+	CapacityDay     int         // day from which CapacityAfter holds, 0 off
+	CapacityAfter   float64     // working hours per day from CapacityDay on
+	Rework          float64     // probability a done ticket is reopened
+	ReworkWip       float64     // added rework probability per open ticket
+	EffortQuantize  []int       // allowed efforts in h, empty for any
+	SplitStreams    bool        // draw the efforts from a stream of their own
+	RampCost        int         // hours to start a ticket before its work
+	MaxWait         int         // days open after which a ticket is promoted
+	Availability    [][]float64 // fractions of the capacity per worker
+	Conwip          int         // constant count of tickets in work of conwip
+	StableJitter    bool        // break ties by a stable random rank first
+	EffortDiscovery float64     // stdev of the misestimate per effort, 0 off
+	Drain           bool        // simulate after Days until all tickets are done
+	DrainDays       int         // days at most to drain, 0 as many as Days
+	StddevNewPerDay float64     // standard deviation of new tickets per day
+	MeanEffortNew   float64     // mean effort of a new ticket in h
+	StddevEffortNew float64     // standard deviation of the effort in h
+	MinEffort       int         // minimal effort of a ticket in h
+	MeanCostOfDelay float64     // mean cost of delay of a ticket per day
+	StddevCostDelay float64     // standard deviation of the cost of delay
+	Capacity        float64     // working hours per day
+	Resolution      int         // burndowns per day, 1 daily, 8 hourly
+	Overhead        float64     // hours per day lost before any ticket work
+	WipLimit        int         // maximum tickets in work for pull strategies
+	StarveDays      int         // days without work a ticket is starved, 0 off
+	SlaDays         int         // leadtime in days to meet, 0 no SLA report
+	SlaPercent      float64     // percentage of tickets to meet the SLA
+	Bootstrap       int         // count of bootstrap resamples, 0 off
+	Verify          bool        // check the invariants after each burndown
+	DueFactor       float64     // allowed leadtime as multiple of the effort
+	ArrivalModel    string      // arrivalDaily or arrivalInterarrival
+	EffortModel     string      // effortNormal or effortExponential
+	AwsjfRemaining  float64     // exponent of the remaining work of awsjf
+	AwsjfAge        float64     // exponent of the days open of awsjf
+	Parallel        float64     // parallelizable fraction of the effort
+	CancelRate      float64     // probability an open ticket is cancelled a day
+	Rounding        string      // rounding of random values to int
+	Resample        bool        // redraw random values below the lowest
+	LearningRate    float64     // more effort per hour for each day in a row
+	PullPolicy      string      // order to pull tickets from backlog into work
+	WorkPolicy      string      // order to work on the tickets in work
+	Strategies      []string    // ids of the strategies to run, empty for all
+	SmallThreshold  int         // maximum effort in h of a small ticket
+	SmallFraction   float64     // fraction of the hours reserved for small tickets
+	Precision       int         // decimal places of the metrics in the text
+}
+
+// the arrival models
+const (
+	// arrivalDaily the count of new tickets per day is gaussian
+	arrivalDaily = "daily"
+	// arrivalInterarrival the time between new tickets is exponential
+	arrivalInterarrival = "interarrival"
+	// arrivalBatch all tickets arrive at day 0
+	arrivalBatch = "batch"
+)
+
+// the effort models
+const (
+	// effortNormal the effort of a ticket is gaussian
+	effortNormal = "normal"
+	// effortExponential the effort of a ticket is exponential with the mean,
+	// the standard deviation is the mean
+	effortExponential = "exponential"
+)
+
+// drainDays return the days simulated at most after Days to drain the
+// tickets, as many as Days unless set
+func (p *Params) drainDays() int {
+	if p.DrainDays > 0 {
+		return p.DrainDays
+	}
+	return p.Days
+}
+
+// horizon return the days simulated at most, more than Days to drain
+func (p *Params) horizon() int {
+	if p.Drain {
+		return p.Days + p.drainDays()
+	}
+	return p.Days
+}
+
+// NewParams create the default parameters
+func NewParams() Params {
+	p := Params{}
+	p.Days = maxPrint
+	p.MeanNewPerDay = 1.0
+	p.BatchSize = 20
+	p.ArrivalTime = 1
+	p.Teams = 1
+	p.Routing = routeRoundRobin
+	p.Tiebreak = tieFifo
+	p.DailyCap = 2
+	p.WipHours = 16
+	p.MaxWait = 5
+	p.Conwip = 3
+	p.ExpediteEffort = 4
+	p.StddevNewPerDay = 1.0
+	p.MeanEffortNew = 6.0
+	p.StddevEffortNew = 4.0
+	p.MinEffort = 1
+	p.MeanCostOfDelay = 5.0
+	p.StddevCostDelay = 3.0
+	p.Capacity = workhoursday
+	p.Resolution = 1
+	p.SmallThreshold = 3
+	p.SmallFraction = 0.25
+	p.Precision = 2
+	p.WipLimit = 3
+	p.StarveDays = 5
+	p.SlaPercent = 85
+	p.DueFactor = 3.0
+	p.ArrivalModel = arrivalDaily
+	p.EffortModel = effortNormal
+	p.AwsjfRemaining = 1
+	p.AwsjfAge = 1
+	p.Parallel = 1
+	p.Rounding = roundHalfAway
+	p.PullPolicy = "fifo"
+	p.WorkPolicy = "sjf"
+	return p
+}
+
+// the rounding modes of random values
+const (
+	roundHalfAway   = "round"      // to nearest, half away from zero
+	roundFloor      = "floor"      // down
+	roundCeil       = "ceil"       // up
+	roundStochastic = "stochastic" // up with the probability of the fraction
+)
+
+// roundValue round the value with the rounding mode
+func roundValue(rng randSource, value float64, rounding string) float64 {
+	switch rounding {
+	case roundFloor:
+		return math.Floor(value)
+	case roundCeil:
+		return math.Ceil(value)
+	case roundStochastic:
+		floor := math.Floor(value)
+		if rng.Float64() < value-floor {
+			return floor + 1
+		}
+		return floor
+	default:
+		return math.Round(value)
+	}
+}
+
+// sampler draw random values from a random generator
+type sampler struct {
+	rng      randSource
+	rounding string // the rounding mode
+	resample bool   // redraw values below the lowest instead of clamping
+}
+
+// maxResample the maximum draws for a value not below the lowest
+const maxResample = 100
+
+// newSampler create a sampler with the rounding of the parameters
+func newSampler(rng randSource, p *Params) *sampler {
+	return &sampler{rng, p.Rounding, p.Resample}
+}
+
 // randomValueInt calculates a random int value from a
 // gaussian distribution with mean and standard deviation
-// not smaller as lowest
-func randomValueInt(mean, stddev float64, lowest int) int {
-	randomValue := rand.NormFloat64()*stddev + mean
-	roundedValue := math.Round(randomValue)
-	value := int(roundedValue)
+// rounded with the rounding mode, not smaller as lowest.
+// If resample redraw values smaller as lowest, clamp after maxResample draws.
+func (s *sampler) randomValueInt(mean, stddev float64, lowest int) int {
+	value := lowest
+	for i := 0; i < maxResample; i++ {
+		randomValue := s.rng.NormFloat64()*stddev + mean
+		roundedValue := roundValue(s.rng, randomValue, s.rounding)
+		value = int(roundedValue)
+		if value >= lowest || !s.resample {
+			break
+		}
+	}
 	if value < lowest {
 		value = lowest
 	}
 	return value
 }
 
+// randomExpInt calculates a random int value from an
+// exponential distribution with mean
+// rounded with the rounding mode, not smaller as lowest.
+func (s *sampler) randomExpInt(mean float64, lowest int) int {
+	value := int(roundValue(s.rng, s.rng.ExpFloat64()*mean, s.rounding))
+	return max(value, lowest)
+}
+
 // ticket the state of a ticket
 type ticket struct {
 	startday int
 	leadtime int
 	endday   int
 	effort   int
+	// deadline the last day to finish the ticket in time
+	deadline int
+	// costofdelay the cost of delay per day the ticket is open
+	costofdelay int
+	// firstwork the day of the first work on the ticket, -1 if never worked
+	firstwork int
 	// remaining the remaining effort of a ticket at a day.
 	// The day is the index in the array.
 	remaining []int
+	// burnday the day after the last burndown, 0 if never burned down
+	burnday int
+	// workday the last day of work on the ticket, -1 if never worked
+	workday int
+	// streak the count of days in a row the ticket was worked until workday
+	streak int
+	// learned the fraction of an hour of effort burned by learning and not
+	// yet taken from the remaining work, carried to the next burndown
+	learned float64
+	// hoursday the hours worked on the ticket on workday
+	hoursday int
+	// workdays the count of days the ticket was worked on
+	workdays int
+	// spent the hours worked on the ticket, without the ramp up
+	spent int
+	// id the index of the ticket in the simulation
+	id int
+	// seq the index of the ticket in order of creation
+	seq int
+	// prereqs the seq of the tickets to be done before work on the ticket
+	prereqs []int
+	// deps the prerequisites in the simulation
+	deps []*ticket
+	// blockeddays the count of days the ticket was blocked by prerequisites
+	blockeddays int
+	// tiekey the random key to break ties, see Params.Tiebreak
+	tiekey float64
+	// rank the stable random rank to break ties, see Params.StableJitter
+	rank uint64
+	// misestimate the believed minus the actual effort at arrival, see
+	// Params.EffortDiscovery
+	misestimate int
+	// parallel the fraction of the effort the workers can share, the rest is
+	// serial, see Params.Parallel
+	parallel float64
+	// expedite the ticket preempts all other work until done
+	expedite bool
+	// reworks the count of times the ticket was reopened when done
+	reworks int
+	// ramped the hours spent to start the ticket, see Params.RampCost
+	ramped int
+	// promoted the ticket was promoted after the maximum wait
+	promoted bool
+	// cancelled the ticket was cancelled before done, see Params.CancelRate
+	cancelled bool
+}
+
+// learningCap the maximum effort burned per hour by learning
+const learningCap = 1.5
+
+// String create representation {startday leadtime endday effort [remaining]}
+func (t ticket) String() string {
+	return fmt.Sprint("{", t.startday, " ", t.leadtime, " ", t.endday, " ",
+		t.effort, " ", t.remaining, "}")
 }
 
 // NewTicket create a new ticket
@@ -61,8 +329,11 @@ func NewTicket(startday, effort, totaldays int) *ticket {
 	t := ticket{}
 	t.startday = startday
 	t.effort = effort
+	t.firstwork = -1
+	t.workday = -1
 	t.remaining = make([]int, totaldays)
 	t.remaining[startday] = effort
+	t.parallel = 1
 	return &t
 }
 
@@ -71,55 +342,159 @@ func (t *ticket) Clone() *ticket {
 	cp := ticket{}
 	cp.startday = t.startday
 	cp.effort = t.effort
+	cp.deadline = t.deadline
+	cp.costofdelay = t.costofdelay
+	cp.firstwork = t.firstwork
+	cp.workday = t.workday
+	cp.streak = t.streak
+	cp.learned = t.learned
+	cp.hoursday = t.hoursday
+	cp.workdays = t.workdays
+	cp.spent = t.spent
+	cp.seq = t.seq
+	cp.prereqs = t.prereqs
+	cp.tiekey = t.tiekey
+	cp.rank = t.rank
+	cp.misestimate = t.misestimate
+	cp.parallel = t.parallel
+	cp.expedite = t.expedite
 	cp.remaining = make([]int, 0, len(t.remaining))
 	cp.remaining = append(cp.remaining, t.remaining...)
 	return &cp
 }
 
-// createTicketsForDay create count new tickets for a day with random effort
-func createTicketsForDay(d, days, count int, meanEffortNew, stddevEffortNew float64,
-	minEffort int) ([]*ticket, int) {
+// duedate calculate the deadline of a ticket from its start day and effort,
+// the allowed leadtime is factor times the days of work for the effort
+func duedate(startday, effort int, factor float64) int {
+	days := math.Ceil(factor * float64(effort) / workhoursday)
+	if days < 1 {
+		days = 1
+	}
+	return startday + int(days) - 1
+}
+
+// quantize return the allowed value nearest to value, the larger one of two
+// as near, value if none is allowed
+func quantize(value int, allowed []int) int {
+	nearest, distance := value, math.MaxInt
+	for _, a := range allowed {
+		d := max(a-value, value-a)
+		if d < distance || d == distance && a > nearest {
+			nearest, distance = a, d
+		}
+	}
+	return nearest
+}
 
+// createTicketsForDay create count new tickets for a day with random effort,
+// print them to out if the days are few, io.Discard prints nothing
+func createTicketsForDay(smp *sampler, p *Params, d, count int,
+	out io.Writer) ([]*ticket, int) {
+	days := p.Days
 	tickets := make([]*ticket, count)
+	horizon := p.horizon()
 	sumEffort := 0
 	for i := 0; i < count; i++ {
-		effort := randomValueInt(meanEffortNew, stddevEffortNew,
-			minEffort)
+		var effort int
+		if p.EffortModel == effortExponential {
+			effort = smp.randomExpInt(p.MeanEffortNew, p.MinEffort)
+		} else {
+			effort = smp.randomValueInt(p.MeanEffortNew, p.StddevEffortNew,
+				p.MinEffort)
+		}
+		effort = quantize(effort, p.EffortQuantize)
 		sumEffort += effort
-		ticket := NewTicket(d, effort, days)
+		ticket := NewTicket(d, effort, horizon)
+		ticket.deadline = duedate(d, effort, p.DueFactor)
+		ticket.costofdelay = smp.randomValueInt(p.MeanCostOfDelay,
+			p.StddevCostDelay, 1)
 		if days <= maxPrint {
-			fmt.Println(d, count, effort, ticket)
+			fmt.Fprintf(out, "%d\t%d\t%d\t%v\n", d, count, effort, ticket)
 		}
 		tickets[i] = ticket
 	}
 	if count == 0 && days <= maxPrint {
-		fmt.Println(d, count)
+		fmt.Fprintf(out, "%d\t%d\n", d, count)
 	}
 	return tickets, sumEffort
 }
 
-// burndownhours burn down a ticket, max for the given hours
-// and return updated hoursleft
-func (t *ticket) burndownhours(day, hoursleft, hours int) int {
+// learningFactor return the effort burned per hour of work on day,
+// growing by rate for each day in a row the ticket was worked before,
+// at most learningCap
+func (t *ticket) learningFactor(day int, rate float64) float64 {
+	before := 0
+	switch t.workday {
+	case day - 1:
+		before = t.streak
+	case day:
+		before = t.streak - 1
+	}
+	return math.Min(1+rate*float64(before), learningCap)
+}
+
+// burndownhours burn down a ticket, max for the given hours,
+// each hour burns factor effort, and return updated hoursleft.
+// The fraction of the effort burned below a whole hour is carried in learned
+// to the next burndown.
+func (t *ticket) burndownhours(day, hoursleft, hours int, factor float64) int {
 	d1 := day + 1
 	workremain := t.remaining[day]
-	wkremaind1 := t.remaining[d1]
-	if wkremaind1 > 0 {
+	if t.burnday == d1 {
 		// function may be called more then once for a day,
-		// then wkremaind1 is already set
-		workremain = wkremaind1
+		// then the remaining of the next day is already set,
+		// even if it is 0
+		workremain = t.remaining[d1]
+	}
+	if workremain > 0 && t.blocked(day) {
+		// no work before the prerequisites are done
+		if t.burnday != d1 {
+			t.blockeddays++
+		}
+		hours = 0
 	}
+	t.burnday = d1
 	if workremain > 0 {
 		// calculate possible burndown
 		if hoursleft > 0 {
-			if workremain < hours {
-				hours = workremain
-			}
 			if hoursleft < hours {
 				hours = hoursleft
 			}
-			workremain -= hours
+			burned := float64(hours)*factor + t.learned
+			work := int(burned)
+			if workremain < work {
+				// spend only the hours needed to finish
+				work = workremain
+				needed := int(math.Ceil((float64(work) - t.learned) / factor))
+				if needed < hours {
+					hours = needed
+				}
+			}
+			t.learned = burned - float64(work)
+			workremain -= work
+			if workremain == 0 {
+				t.learned = 0
+			}
 			hoursleft -= hours
+			if hours > 0 {
+				if t.firstwork < 0 {
+					t.firstwork = day
+				}
+				t.spent += hours
+				switch t.workday {
+				case day:
+					t.hoursday += hours
+				case day - 1:
+					t.streak++
+					t.hoursday = hours
+					t.workdays++
+				default:
+					t.streak = 1
+					t.hoursday = hours
+					t.workdays++
+				}
+				t.workday = day
+			}
 		}
 		// update ticket stats for actual day for ticket in work
 		t.endday = day
@@ -129,27 +504,223 @@ func (t *ticket) burndownhours(day, hoursleft, hours int) int {
 	return hoursleft
 }
 
+// waitdays return the days the ticket waited for the first work,
+// for a ticket never worked the days waited until day
+func (t *ticket) waitdays(day int) int {
+	if t.firstwork >= 0 {
+		return t.firstwork - t.startday
+	}
+	return day - t.startday
+}
+
+// current return the remaining work of the ticket during day,
+// after the burndowns of the day so far
+func (t *ticket) current(day int) int {
+	if t.burnday == day+1 {
+		return t.remaining[day+1]
+	}
+	return t.remaining[day]
+}
+
+// hoursOn return the hours worked on the ticket on day so far
+func (t *ticket) hoursOn(day int) int {
+	if t.workday == day {
+		return t.hoursday
+	}
+	return 0
+}
+
+// capOn return the hours left to work on the ticket on day
+// within a cap of hours per day
+func (t *ticket) capOn(day, hours int) int {
+	if h := hours - t.hoursOn(day); h > 0 {
+		return h
+	}
+	return 0
+}
+
+// done reports whether the ticket has no remaining work at day and was not
+// cancelled
+func (t *ticket) done(day int) bool {
+	return t.remaining[day] == 0 && !t.cancelled
+}
+
+// strategy a scheduling strategy, burndownaday burns down the tickets of a day
+type strategy struct {
+	id           string
+	name         string
+	description  string
+	burndownaday func(*simulation, int)
+}
+
+// strategies the registered strategies in order of comparison
+var strategies = []strategy{
+	{"equal", "Equal working",
+		"work on each ticket max 2h per day", burndownMaxWip},
+	{"oldest", "Oldest first",
+		"work on the tickets in order of arrival", burndownOldestFirst},
+	{"sjf", "Shortest first",
+		"work on the ticket with the shortest remaining work first", burndownSjf},
+	{"osjf", "Oldest, shortest first",
+		"work on the older tickets first, then on the shortest", burndownOsjf},
+	{"awsjf", "Age weighted, shortest first",
+		"work on the smallest remaining work^-awsjf-remaining divided by days" +
+			" open^-awsjf-age first",
+		burndownAwsjf},
+	{"pull", "Pull oldest first, WIP limited",
+		"pull the oldest tickets up to -wip into work, work max 2h per day on each",
+		burndownPullFifo},
+	{"edf", "Earliest deadline first",
+		"work on the ticket with the earliest deadline first, see -due-factor",
+		burndownMinLateness},
+	{"swarm", "Swarm",
+		"work on the ticket in work until done, then pull the oldest, WIP 1",
+		burndownSwarm},
+	{"kanban", "Kanban, pull and work policy, WIP limited",
+		"pull by -pull-policy up to -wip into work, work by -work-policy",
+		burndownKanban},
+	{"cd3", "Cost of delay divided by duration",
+		"work on the highest cost of delay per remaining work first",
+		burndownCd3},
+	{"small", "Small tickets batched first",
+		"work on tickets of at most -small-threshold h with" +
+			" -small-capacity-fraction of the hours first, then on the others",
+		burndownSmallBatchFirst},
+	{"alt", "Alternating oldest and shortest first",
+		"work on the oldest tickets first on odd days, on the shortest first" +
+			" on even days",
+		burndownAlternating},
+	{"cpull", "Capped pull, WIP limited",
+		"pull the oldest tickets up to -wip into work, work max -daily-cap h per" +
+			" day on each, no other work",
+		burndownCappedPull},
+	{"hpull", "Pull oldest first, WIP limited by hours",
+		"pull the oldest tickets into work while the remaining work in work is" +
+			" below -wip-hours, work on them oldest first",
+		burndownWipHours},
+	{"maxwait", "Shortest first, promoted after max wait",
+		"work on the tickets open longer than -max-wait days oldest first," +
+			" then on the shortest first",
+		burndownMaxWait},
+	{"conwip", "CONWIP, constant WIP",
+		"keep -conwip tickets in work, admit the oldest when one is done," +
+			" share the hours evenly among them",
+		burndownConwip},
+	{"wspt", "Weighted shortest processing time",
+		"work on the ticket in work until done, then start the one with the" +
+			" highest cost of delay divided by its effort (Smith's rule)",
+		burndownWspt},
+}
+
+// findStrategy return the registered strategy with id, false if none
+func findStrategy(id string) (strategy, bool) {
+	for _, st := range strategies {
+		if st.id == id {
+			return st, true
+		}
+	}
+	return strategy{}, false
+}
+
 // simulation the set of all tickets
 type simulation struct {
+	id           string
 	name         string
 	burndownaday func(*simulation, int)
+	params       *Params
 	tickets      []*ticket
+	lastday      int // the last day simulated, -1 before the first day
+	// inwork the tickets pulled from the backlog into work
+	inwork []*ticket
+	// lastworked the ticket worked on last and the day of the work
+	lastworked    *ticket
+	lastworkedday int
+	// switches the count of context switches per day
+	switches []int
+	// worked the hours worked on tickets per day
+	worked []int
+	// intake the random source to admit new tickets, nil without feedback
+	intake *rand.Rand
+	// rejected the count of new tickets rejected at intake
+	rejected int
+	// trace the allocations of hours to tickets, see Params.Trace
+	trace []allocation
+	// sample the sampled tickets, see -sample-tickets, nil if none
+	sample *reservoir
+	// bySeq the tickets admitted by seq, without the expedite tickets
+	bySeq map[int]*ticket
+	// slot the part of the day burned down, see Params.Resolution
+	slot int
+	// expedited the hours of the slot used by expedite tickets
+	expedited int
+	// reworkWip, doneWip the open tickets at reopened and final completions
+	reworkWip, doneWip welford
+	// ramped the hours spent to start tickets, see Params.RampCost
+	ramped int
+	// promotions the count of tickets promoted after the maximum wait
+	promotions int
+	// realizedWip the count of tickets in work per slot of conwip
+	realizedWip welford
 }
 
-// NewSimulation create a simulation
-func NewSimulation(name string, burndownaday func(*simulation, int), size int) simulation {
+// NewSimulation create a simulation of a strategy
+func NewSimulation(st strategy, p *Params, size int) simulation {
 	sim := simulation{}
-	sim.name = name
-	sim.burndownaday = burndownaday
+	sim.id = st.id
+	sim.name = st.name
+	sim.burndownaday = st.burndownaday
+	sim.params = p
 	sim.tickets = make([]*ticket, 0, size)
+	sim.lastday = -1
+	sim.bySeq = make(map[int]*ticket)
+	sim.switches = make([]int, p.horizon())
+	sim.worked = make([]int, p.horizon())
+	if p.IntakeFeedback > 0 {
+		// the same draws for each simulation
+		sim.intake = rand.New(rand.NewSource(p.Seed + 3))
+	}
 	return sim
 }
 
-// addTickets add a copy of the given tickets to the simulation
+// admitProbability return the probability to admit a new ticket at day,
+// 1 / (1 + feedback * open tickets)
+func (sim *simulation) admitProbability(day int) float64 {
+	open := 0
+	for _, t := range sim.tickets {
+		if t.remaining[day] > 0 {
+			open++
+		}
+	}
+	return 1 / (1 + sim.params.IntakeFeedback*float64(open))
+}
+
+// addTickets add a copy of the given tickets to the simulation.
+// With intake feedback a ticket is rejected by the admit probability.
 func (sim simulation) addTickets(ts []*ticket) simulation {
 	sts := sim.tickets
+	admit := 1.0
+	if sim.intake != nil && len(ts) > 0 {
+		admit = sim.admitProbability(ts[0].startday)
+	}
 	for _, t := range ts {
+		if sim.intake != nil && sim.intake.Float64() >= admit {
+			sim.rejected++
+			if sim.sample != nil {
+				sim.sample.add(t.seq, nil)
+			}
+			continue
+		}
 		tcp := t.Clone()
+		if sim.sample != nil {
+			sim.sample.add(tcp.seq, tcp)
+		}
+		tcp.id = len(sts)
+		if !tcp.expedite {
+			sim.bySeq[tcp.seq] = tcp
+		}
+		if sim.params.Dependencies > 0 {
+			sim.resolveDeps(tcp)
+		}
 		sts = append(sts, tcp)
 	}
 	sim.tickets = sts
@@ -165,64 +736,508 @@ func (sim *simulation) copyTickets() []*ticket {
 	return tscp
 }
 
+// openTickets return the tickets with remaining work at day in order of arrival
+func (sim *simulation) openTickets(day int) []*ticket {
+	ts := make([]*ticket, 0, len(sim.tickets))
+	for _, t := range sim.tickets {
+		if t.current(day) > 0 {
+			ts = append(ts, t)
+		}
+	}
+	return ts
+}
+
+// verify check that no ticket has a negative remaining work the day after day
+func (sim *simulation) verify(day int) error {
+	for i, t := range sim.tickets {
+		if r := t.remaining[day+1]; r < 0 {
+			return fmt.Errorf("%s: ticket %d remaining %d < 0 after burndown of day %d",
+				sim.name, i, r, day)
+		}
+	}
+	return nil
+}
+
+// wipPerDay return the count of open tickets for each day
+// up to the last day simulated
+func (sim simulation) wipPerDay() []int {
+	wip := make([]int, sim.lastday+1)
+	for _, t := range sim.tickets {
+		for d := t.startday; d <= sim.lastday; d++ {
+			if t.remaining[d] > 0 {
+				wip[d]++
+			}
+		}
+	}
+	return wip
+}
+
+// queuePerDay return the count of open tickets not started yet for each day
+// up to the last day simulated, a ticket is started on the day first worked
+func (sim simulation) queuePerDay() []int {
+	queue := make([]int, sim.lastday+1)
+	for _, t := range sim.tickets {
+		for d := t.startday; d <= sim.lastday; d++ {
+			if t.remaining[d] > 0 && (t.firstwork < 0 || t.firstwork > d) {
+				queue[d]++
+			}
+		}
+	}
+	return queue
+}
+
+// completedPerDay return the count of tickets done on each day
+// up to the last day simulated
+func (sim simulation) completedPerDay() []int {
+	completed := make([]int, sim.lastday+1)
+	for _, t := range sim.tickets {
+		if t.effort > 0 && t.done(sim.lastday) {
+			completed[t.endday]++
+		}
+	}
+	return completed
+}
+
+// throughput return the mean count of tickets done per day
+func (sim simulation) throughput() float64 {
+	cnt := 0
+	for _, t := range sim.tickets {
+		if t.done(sim.lastday) {
+			cnt++
+		}
+	}
+	return float64(cnt) / float64(sim.lastday+1)
+}
+
 // statsLeadTime return average and standard deviation
 // and sum of mean and stdev of tickets leadtime
 func (sim simulation) statsLeadTime() (float64, float64, float64) {
-	var sum float64 = 0.0
-	var sumSq float64 = 0.0
+	var w welford
 	for _, t := range sim.tickets {
-		l := float64(t.leadtime)
-		sum += l
-		sumSq += l * l
-	}
-	// calculate the mean/std.dev
-	l := float64(len(sim.tickets))
-	meanSq := sumSq / l
-	mean := sum / l
-	stdev := math.Sqrt(meanSq - mean*mean)
+		if t.cancelled {
+			continue
+		}
+		w.add(float64(t.leadtime))
+	}
+	mean, stdev := w.mean(), w.stdev()
 	return mean, stdev, mean + stdev
 }
 
+// starvedTickets return the tickets waiting more than k days for the first
+// work, the longest waiting first. Tickets without effort never starve.
+func (sim simulation) starvedTickets(k int) []*ticket {
+	lastday := sim.lastday
+	starved := make([]*ticket, 0)
+	for _, t := range sim.tickets {
+		if t.effort > 0 && !t.cancelled && t.waitdays(lastday) > k {
+			starved = append(starved, t)
+		}
+	}
+	sort.SliceStable(starved, func(i, j int) bool {
+		return starved[i].waitdays(lastday) > starved[j].waitdays(lastday)
+	})
+	return starved
+}
+
+// starvationReport create the report of the tickets waiting more than
+// k days for the first work, print details for at most maxPrint tickets
+func (sim simulation) starvationReport(k int) string {
+	var buf bytes.Buffer
+	lastday := sim.lastday
+	starved := sim.starvedTickets(k)
+	buf.WriteString(fmt.Sprintf("Starved tickets waiting more than %d days: %d\n",
+		k, len(starved)))
+	if len(starved) == 0 {
+		return buf.String()
+	}
+	buf.WriteString("# startday effort waited firstwork\n")
+	for i, t := range starved {
+		if i == maxPrint {
+			buf.WriteString("...\n")
+			break
+		}
+		buf.WriteString(fmt.Sprintln(t.startday, t.effort, t.waitdays(lastday),
+			t.firstwork))
+	}
+	return buf.String()
+}
+
+// backlogHours return the sum of remaining work of all tickets for each day
+// up to the last day simulated
+func (sim simulation) backlogHours() []int {
+	backlog := make([]int, sim.lastday+1)
+	for _, t := range sim.tickets {
+		for d := t.startday; d <= sim.lastday; d++ {
+			backlog[d] += t.remaining[d]
+		}
+	}
+	return backlog
+}
+
+// slope return the slope of the linear regression of values over their index,
+// 0 for less than two values
+func slope(values []int) float64 {
+	n := float64(len(values))
+	if n < 2 {
+		return 0
+	}
+	var sumX, sumY, sumXY, sumXX float64
+	for i, v := range values {
+		x := float64(i)
+		y := float64(v)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	return (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+}
+
+// costOfDelay return the total cost of delay of the tickets,
+// the cost of delay per day times the leadtime
+func (sim simulation) costOfDelay() int {
+	total := 0
+	for _, t := range sim.tickets {
+		if !t.cancelled {
+			total += t.costofdelay * t.leadtime
+		}
+	}
+	return total
+}
+
+// leadtimes return the sorted leadtimes of the tickets not cancelled
+func (sim simulation) leadtimes() []int {
+	lts := make([]int, 0, len(sim.tickets))
+	for _, t := range sim.tickets {
+		if !t.cancelled {
+			lts = append(lts, t.leadtime)
+		}
+	}
+	sort.Ints(lts)
+	return lts
+}
+
+// percentile return the leadtime not exceeded by percent of the tickets,
+// by nearest rank, 0 if there are no tickets
+func (sim simulation) percentile(percent float64) int {
+	return nearestRank(sim.leadtimes(), percent)
+}
+
+// slaCompliance return the percentage of tickets with a leadtime of at most
+// days
+func (sim simulation) slaCompliance(days int) float64 {
+	lts := sim.leadtimes()
+	within := sort.SearchInts(lts, days+1)
+	return 100 * float64(within) / float64(len(lts))
+}
+
+// slaReport create the report whether percent of the tickets have a leadtime
+// of at most days, with the leadtime at percent actually achieved
+func (sim simulation) slaReport(days int, percent float64) string {
+	if len(sim.leadtimes()) == 0 {
+		return fmt.Sprintf("SLA %.0f%% within %d days: %s\n", percent, days,
+			notAvailable)
+	}
+	compliance := sim.slaCompliance(days)
+	verdict := "FAIL"
+	if compliance >= percent {
+		verdict = "PASS"
+	}
+	frmt := withPrecision("SLA %.0f%% within %d days: %.1f%% %s, p%.0f: %d"+
+		" days\n", sim.params.Precision)
+	return fmt.Sprintf(frmt, percent, days, compliance, verdict, percent,
+		sim.percentile(percent))
+}
+
+// leadtimeBySize return the mean leadtime of the small and of the large
+// tickets, NaN if there are none
+func (sim simulation) leadtimeBySize() (small, large float64) {
+	var sl, ll []int
+	for _, t := range sim.tickets {
+		if t.cancelled {
+			continue
+		}
+		if sim.params.small(t) {
+			sl = append(sl, t.leadtime)
+		} else {
+			ll = append(ll, t.leadtime)
+		}
+	}
+	return meanOf(sl), meanOf(ll)
+}
+
+// maxLateness return the maximum lateness in days of the tickets, the day
+// done minus the deadline. Open tickets count with the last day simulated,
+// a lower bound of their lateness. Return 0 if there are no tickets.
+func (sim simulation) maxLateness() int {
+	first := true
+	maxLate := 0
+	for _, t := range sim.tickets {
+		if t.effort == 0 || t.cancelled {
+			continue
+		}
+		end := sim.lastday
+		if t.done(sim.lastday) {
+			end = t.endday
+		}
+		late := end - t.deadline
+		if first || late > maxLate {
+			maxLate = late
+			first = false
+		}
+	}
+	return maxLate
+}
+
+// contextSwitches return the total count of context switches
+// and the mean count per day
+func (sim simulation) contextSwitches() (int, float64) {
+	total := 0
+	for d := 0; d <= sim.lastday; d++ {
+		total += sim.switches[d]
+	}
+	return total, float64(total) / float64(sim.lastday+1)
+}
+
+// started return the count of tickets started, worked on or ramped up
+func (sim simulation) started() int {
+	n := 0
+	for _, t := range sim.tickets {
+		if t.firstwork >= 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// idle return the count of days with hours not worked on tickets
+// and the total hours not worked
+func (sim simulation) idle() (int, int) {
+	days, hours := 0, 0
+	for d := 0; d <= sim.lastday; d++ {
+		if h := sim.hoursOfDay(d) - sim.worked[d]; h > 0 {
+			days++
+			hours += h
+		}
+	}
+	return days, hours
+}
+
 // String create nice representation
 func (sim simulation) String() string {
+	places := sim.params.Precision
 	var buf bytes.Buffer
 	buf.WriteString(fmt.Sprintln(sim.name))
-	m, s, ms := sim.statsLeadTime()
-	frmt := "Leadtime of tickets mean: %.2f stdev: %.2f mean+stdev(%v): %.2f\n"
-	buf.WriteString(fmt.Sprintf(frmt, m, s, "74%", ms))
+	done, sumLead, sumCompletion := sim.sumCompletionTimes()
+	if len(sim.tickets) == 0 {
+		buf.WriteString("Leadtime of tickets: " + notAvailable + "\n")
+	} else {
+		m, s, ms := sim.statsLeadTime()
+		frmt := withPrecision("Leadtime of tickets mean: %.2f stdev: %.2f"+
+			" mean+stdev(%v): %.2f\n", places)
+		buf.WriteString(fmt.Sprintf(frmt, m, s, "74%", ms))
+		if sim.params.hasCalendar() {
+			m, s, ms := sim.statsWorkingLeadTime()
+			frmt := withPrecision("Leadtime in working days mean: %.2f stdev: %.2f"+
+				" mean+stdev(%v): %.2f\n", places)
+			buf.WriteString(fmt.Sprintf(frmt, m, s, "74%", ms))
+		}
+		buf.WriteString(sim.startDelayReport())
+	}
+	buf.WriteString(fmt.Sprintf("Max lateness of tickets: %d days\n",
+		sim.maxLateness()))
+	small, large := sim.leadtimeBySize()
+	buf.WriteString(fmt.Sprintf("Leadtime mean of small tickets (<= %d h): %s"+
+		" large: %s\n", sim.params.SmallThreshold, orNA("%.2f", small, places),
+		orNA("%.2f", large, places)))
+	buf.WriteString(fmt.Sprintf("Correlation of effort and leadtime: %s\n",
+		orNA("%.2f", sim.effortLeadtimeCorrelation(), places)))
+	if sim.params.SlaDays > 0 {
+		buf.WriteString(sim.slaReport(sim.params.SlaDays, sim.params.SlaPercent))
+	}
+	if sim.params.Bootstrap > 0 {
+		buf.WriteString(sim.bootstrapReport(sim.params.Bootstrap))
+	}
+	buf.WriteString(fmt.Sprintf("Cost of delay (weighted leadtime): %d\n",
+		sim.costOfDelay()))
+	buf.WriteString(fmt.Sprintf("Done tickets: %d, sum of leadtimes: %d,"+
+		" sum of completion times: %d\n", done, sumLead, sumCompletion))
+	buf.WriteString(fmt.Sprintf("Weighted sum of completion times (cost of"+
+		" delay as weight): %d\n", sim.weightedCompletion()))
+	total, perDay := sim.contextSwitches()
+	frmt := withPrecision("Context switches: %d, per day: %.2f\n", places)
+	buf.WriteString(fmt.Sprintf(frmt, total, perDay))
+	if done == 0 {
+		buf.WriteString("Flow of done tickets: " + notAvailable + "\n")
+	} else {
+		buf.WriteString(sim.flowReport())
+	}
+	buf.WriteString(fmt.Sprintf("Work days per worked ticket: %s\n",
+		orNA("%.2f", sim.meanWorkdays(), places)))
+	buf.WriteString(sim.fastPathReport())
+	idleDays, idleHours := sim.idle()
+	buf.WriteString(fmt.Sprintf("Idle days: %d, idle hours: %d\n",
+		idleDays, idleHours))
+	policyDays, policyHours := sim.policyIdle()
+	buf.WriteString(fmt.Sprintf("Idle with eligible work open days: %d,"+
+		" hours: %d\n", policyDays, policyHours))
+	buf.WriteString(fmt.Sprintf("Flow debt (remaining work deferred): %d"+
+		" h days\n", sim.flowDebt()))
+	queue, wip := sim.queuePerDay(), sim.wipPerDay()
+	started := make([]int, len(wip))
+	maxStarted, maxQueue := 0, 0
+	for d := range wip {
+		started[d] = wip[d] - queue[d]
+		maxStarted = max(maxStarted, started[d])
+		maxQueue = max(maxQueue, queue[d])
+	}
+	frmt = withPrecision("Open tickets per day started mean: %.2f"+
+		" max: %d, queued mean: %.2f max: %d\n", places)
+	buf.WriteString(fmt.Sprintf(frmt, meanOf(started), maxStarted,
+		meanOf(queue), maxQueue))
+	trend := slope(sim.backlogHours())
+	growing := ""
+	if trend*float64(sim.lastday+1) > sim.params.Capacity {
+		growing = " (growing)"
+	}
+	buf.WriteString(fmt.Sprintf(withPrecision("Backlog trend: %+.2f h/day%s\n",
+		places), trend, growing))
+	buf.WriteString(sim.steadyReport())
+	buf.WriteString(sim.openAgingReport())
+	if sim.params.CapacityDay > 0 {
+		buf.WriteString(sim.capacityChangeReport())
+	}
+	if sim.params.reworking() {
+		buf.WriteString(sim.reworkReport())
+	}
+	if sim.params.CancelRate > 0 {
+		buf.WriteString(sim.cancelReport())
+	}
+	if sim.id == "conwip" {
+		buf.WriteString(fmt.Sprintf("Realized WIP mean: %s, target: %d\n",
+			orNA("%.2f", sim.realizedWip.mean(), places), sim.params.Conwip))
+	}
+	if sim.id == "maxwait" {
+		buf.WriteString(fmt.Sprintf("Promoted after max wait of %d days: %d"+
+			" tickets\n", sim.params.MaxWait, sim.promotions))
+	}
+	if sim.params.RampCost > 0 {
+		buf.WriteString(fmt.Sprintf("Ramp cost: %d h for %d started tickets\n",
+			sim.ramped, sim.started()))
+	}
+	if sim.params.StarveDays > 0 {
+		buf.WriteString(sim.starvationReport(sim.params.StarveDays))
+	}
+	if sim.params.Dependencies > 0 {
+		buf.WriteString(sim.dependencyReport())
+	}
+	if sim.intake != nil {
+		buf.WriteString(fmt.Sprintf("Rejected at intake: %d tickets\n",
+			sim.rejected))
+	}
+	if sim.params.ArrivalModel == arrivalBatch {
+		buf.WriteString(fmt.Sprintf("Completion day of done tickets mean: %s"+
+			" last: %d\n", orNA("%.2f", meanOf(sim.doneLeadtimes()), places),
+			sim.percentile(100)))
+	}
 	if len(sim.tickets) <= maxPrint {
-		header := "# startday leadtime endday effort [remaining per day]\n"
-		buf.WriteString(header)
+		tw := tabwriter.NewWriter(&buf, 0, 0, 1, ' ', 0)
+		fmt.Fprintln(tw, "# id\t"+ticketColumns)
 		for i, t := range sim.tickets {
-			buf.WriteString(fmt.Sprintln(i, *t))
+			t.writeRow(tw, i)
 		}
+		tw.Flush()
 	}
 	return buf.String()
 }
 
-// workhoursday working hours per day
+// ticketColumns the tab separated columns of the records of the tickets
+// after the id
+const ticketColumns = "startday\tleadtime\tendday\teffort\tremaining per day"
+
+// writeRow write the record of the ticket with id as tab separated row to w
+func (t *ticket) writeRow(w io.Writer, id int) {
+	fmt.Fprintf(w, "%d\t%d\t%d\t%d\t%d", id, t.startday, t.leadtime, t.endday,
+		t.effort)
+	for _, r := range t.remaining {
+		fmt.Fprintf(w, "\t%d", r)
+	}
+	fmt.Fprintln(w)
+}
+
+// workhoursday working hours per day by default
 const workhoursday = 8
 
+// effectiveCapacity return the mean hours per day for ticket work,
+// the capacity by the mean availability of the workers less the overhead
+func (p *Params) effectiveCapacity() float64 {
+	return math.Max(p.Capacity*p.meanAvailability()-p.Overhead, 0)
+}
+
+// hoursOfDay return the working hours on tickets of day, the fraction of the
+// effective capacity accrues over the days
+func (sim *simulation) hoursOfDay(day int) int {
+	return int(math.Floor(sim.params.hoursUpTo(day+1)) -
+		math.Floor(sim.params.hoursUpTo(day)))
+}
+
+// hoursUpTo return the hours for ticket work of the days before day,
+// the effective capacity changes on CapacityDay to the one of CapacityAfter.
+// With availabilities of the workers each day has its own capacity.
+func (p *Params) hoursUpTo(day int) float64 {
+	if len(p.Availability) > 0 {
+		hours := 0.0
+		for d := 0; d < day; d++ {
+			c := p.Capacity
+			if p.CapacityDay > 0 && d >= p.CapacityDay {
+				c = p.CapacityAfter
+			}
+			hours += math.Max(c*p.availability(d)-p.Overhead, 0)
+		}
+		return hours
+	}
+	c := p.effectiveCapacity()
+	if p.CapacityDay == 0 || day <= p.CapacityDay {
+		return float64(day) * c
+	}
+	after := math.Max(p.CapacityAfter-p.Overhead, 0)
+	return float64(p.CapacityDay)*c + float64(day-p.CapacityDay)*after
+}
+
+// slotHours return the working hours of the current slot of day,
+// the hours of the day split evenly over the slots
+func (sim *simulation) slotHours(day int) int {
+	h := sim.hoursOfDay(day)
+	r := sim.params.Resolution
+	return (sim.slot+1)*h/r - sim.slot*h/r
+}
+
+// hoursOfSlot return the working hours of the strategy on tickets of the
+// current slot of day, the hours not used by expedite tickets
+func (sim *simulation) hoursOfSlot(day int) int {
+	return sim.slotHours(day) - sim.expedited
+}
+
 // burndownMaxWip burn down maximum number of tickets in work, try each 2h for a day
 func burndownMaxWip(sim *simulation, day int) {
 	hourswork := 2
-	hoursleft := workhoursday
+	hoursleft := sim.hoursOfSlot(day)
 	for _, t := range (*sim).tickets {
-		hoursleft = t.burndownhours(day, hoursleft, hourswork)
+		hoursleft = sim.burn(t, day, hoursleft, t.capOn(day, hourswork))
 	}
 	if hoursleft > 0 {
 		// burn hours left
 		for _, t := range (*sim).tickets {
-			hoursleft = t.burndownhours(day, hoursleft, hoursleft)
+			hoursleft = sim.burn(t, day, hoursleft, hoursleft)
 		}
 	}
 }
 
 // burndownOldestFirst burn down the oldest tickets first
 func burndownOldestFirst(sim *simulation, day int) {
-	hoursleft := workhoursday
+	hoursleft := sim.hoursOfSlot(day)
 	for _, t := range (*sim).tickets {
-		hoursleft = t.burndownhours(day, hoursleft, hoursleft)
+		hoursleft = sim.burn(t, day, hoursleft, hoursleft)
 	}
 }
 
@@ -230,14 +1245,42 @@ func burndownOldestFirst(sim *simulation, day int) {
 func burndownSjf(sim *simulation, day int) {
 	// copy sim.tickets and sort copy, then burn down
 	tscp := sim.copyTickets()
-	sort.Slice(tscp, func(i, j int) bool {
-		ti := tscp[i]
-		tj := tscp[j]
-		return ti.remaining[day] < tj.remaining[day]
+	sim.sortTickets(tscp, day, func(ti, tj *ticket) bool {
+		return ti.believed(day) < tj.believed(day)
+	})
+	hoursleft := sim.hoursOfSlot(day)
+	for _, t := range tscp {
+		hoursleft = sim.burn(t, day, hoursleft, hoursleft)
+	}
+}
+
+// burndownMaxWait burn down the tickets open longer than the maximum wait
+// oldest first, then the other tickets shortest first. Count the tickets
+// promoted.
+func burndownMaxWait(sim *simulation, day int) {
+	tscp := sim.copyTickets()
+	promoted := func(t *ticket) bool {
+		return t.current(day) > 0 && day-t.startday > sim.params.MaxWait
+	}
+	for _, t := range tscp {
+		if !t.promoted && promoted(t) {
+			t.promoted = true
+			sim.promotions++
+		}
+	}
+	sim.sortTickets(tscp, day, func(ti, tj *ticket) bool {
+		pi, pj := promoted(ti), promoted(tj)
+		switch {
+		case pi != pj:
+			return pi
+		case pi:
+			return ti.startday < tj.startday
+		}
+		return ti.believed(day) < tj.believed(day)
 	})
-	hoursleft := workhoursday
+	hoursleft := sim.hoursOfSlot(day)
 	for _, t := range tscp {
-		hoursleft = t.burndownhours(day, hoursleft, hoursleft)
+		hoursleft = sim.burn(t, day, hoursleft, hoursleft)
 	}
 }
 
@@ -245,63 +1288,707 @@ func burndownSjf(sim *simulation, day int) {
 func burndownOsjf(sim *simulation, day int) {
 	// copy sim and sort copy, then burn down
 	tscp := sim.copyTickets()
-	sort.Slice(tscp, func(i, j int) bool {
-		ti := tscp[i]
-		tj := tscp[j]
-		if ti.startday < tj.startday {
-			return true
+	sim.sortTickets(tscp, day, func(ti, tj *ticket) bool {
+		if ti.startday != tj.startday {
+			return ti.startday < tj.startday
 		}
-		return ti.remaining[day] < tj.remaining[day]
+		return ti.believed(day) < tj.believed(day)
 	})
-	hoursleft := workhoursday
+	hoursleft := sim.hoursOfSlot(day)
 	for _, t := range tscp {
-		hoursleft = t.burndownhours(day, hoursleft, hoursleft)
+		hoursleft = sim.burn(t, day, hoursleft, hoursleft)
 	}
 }
 
+// awsjfWeight return the remaining work of the ticket raised to the exponent
+// a divided by the days it is open including day raised to the exponent b,
+// in floating point not to truncate small ratios to 0. With a=1, b=0 it
+// orders shortest first, with a=0, b=1 oldest first.
+func awsjfWeight(t *ticket, day int, a, b float64) float64 {
+	return math.Pow(float64(t.believed(day)), a) /
+		math.Pow(float64(day+1-t.startday), b)
+}
+
 // burndownAwsjf burn down age weighted, shortest job first
 func burndownAwsjf(sim *simulation, day int) {
+	a, b := sim.params.AwsjfRemaining, sim.params.AwsjfAge
+	burndownByWeight(sim, day, func(t *ticket) float64 {
+		return awsjfWeight(t, day, a, b)
+	})
+}
+
+// burndownByWeight burn down the tickets with the lowest weight first
+func burndownByWeight(sim *simulation, day int, weight func(t *ticket) float64) {
 	// copy sim and sort copy, then burn down
 	tscp := sim.copyTickets()
-	sort.Slice(tscp, func(i, j int) bool {
-		ti := tscp[i]
-		tj := tscp[j]
-		wi := day + 1 - ti.startday
-		wj := day + 1 - tj.startday
-		return ti.remaining[day]/wi < tj.remaining[day]/wj
+	sim.sortTickets(tscp, day, func(ti, tj *ticket) bool {
+		return weight(ti) < weight(tj)
 	})
-	hoursleft := workhoursday
+	hoursleft := sim.hoursOfSlot(day)
 	for _, t := range tscp {
-		hoursleft = t.burndownhours(day, hoursleft, hoursleft)
+		hoursleft = sim.burn(t, day, hoursleft, hoursleft)
 	}
 }
 
-// simulationset the set of simulations
-type simulationset []simulation
-
-// NewSimulationset create the set of simulations
-func NewSimulationset(days int) simulationset {
-	sz := days * 3 / 2 // some more size avoid reallocation
-	cnt := 5
-	simset := make(simulationset, cnt)
-	simset[0] = NewSimulation("Equal working", burndownMaxWip, sz)
-	simset[1] = NewSimulation("Oldest first", burndownOldestFirst, sz)
-	simset[2] = NewSimulation("Shortest first", burndownSjf, sz)
-	simset[3] = NewSimulation("Oldest, shortest first", burndownOsjf, sz)
-	simset[4] = NewSimulation("Age weighted, shortest first", burndownAwsjf, sz)
-	return simset
+// burndownMinLateness burn down the ticket with the earliest deadline first,
+// this minimizes the maximum lateness
+func burndownMinLateness(sim *simulation, day int) {
+	// copy sim and sort copy, then burn down
+	tscp := sim.copyTickets()
+	sim.sortTickets(tscp, day, func(ti, tj *ticket) bool {
+		return ti.deadline < tj.deadline
+	})
+	hoursleft := sim.hoursOfSlot(day)
+	for _, t := range tscp {
+		hoursleft = sim.burn(t, day, hoursleft, hoursleft)
+	}
 }
 
-func (simset simulationset) String() string {
-	var buf bytes.Buffer
-	for _, s := range simset {
-		buf.WriteString(fmt.Sprintln(s))
+// burndownCd3 burn down the ticket with the highest cost of delay divided
+// by the remaining work first (CD3), Smith's rule reprioritized with the
+// remaining work, a heuristic with tickets arriving over time
+func burndownCd3(sim *simulation, day int) {
+	// copy sim and sort copy, then burn down
+	tscp := sim.copyTickets()
+	cd3 := func(t *ticket) float64 {
+		return float64(t.costofdelay) / float64(t.believed(day))
+	}
+	sim.sortTickets(tscp, day, func(ti, tj *ticket) bool {
+		return cd3(ti) > cd3(tj)
+	})
+	hoursleft := sim.hoursOfSlot(day)
+	for _, t := range tscp {
+		hoursleft = sim.burn(t, day, hoursleft, hoursleft)
 	}
-	return buf.String()
 }
 
-// addTickets add the tickets to each simulation
-func (simset simulationset) addTickets(ts []*ticket) simulationset {
+// burndownWspt burn down the ticket in work until done, then start the
+// waiting ticket with the highest cost of delay divided by its effort as
+// believed at arrival, Smith's rule without preemption. It minimizes the
+// weighted sum of completion times if all tickets are there at the start,
+// with tickets arriving over time it is a heuristic.
+func burndownWspt(sim *simulation, day int) {
+	// copy sim and sort copy, tickets in work before waiting tickets
+	tscp := sim.copyTickets()
+	wspt := func(t *ticket) float64 {
+		return float64(t.costofdelay) / float64(max(t.effort+t.misestimate, 1))
+	}
+	sim.sortTickets(tscp, day, func(ti, tj *ticket) bool {
+		si, sj := ti.firstwork >= 0, tj.firstwork >= 0
+		if si != sj {
+			return si
+		}
+		return !si && wspt(ti) > wspt(tj)
+	})
+	hoursleft := sim.hoursOfSlot(day)
+	for _, t := range tscp {
+		hoursleft = sim.burn(t, day, hoursleft, hoursleft)
+	}
+}
+
+// burndownSwarm burn down the ticket in work with all hours until it is done,
+// then pull the oldest ticket into work, WIP is 1
+func burndownSwarm(sim *simulation, day int) {
+	// copy sim and sort copy, tickets in work before waiting tickets
+	tscp := sim.copyTickets()
+	sim.sortTickets(tscp, day, func(ti, tj *ticket) bool {
+		return ti.firstwork >= 0 && tj.firstwork < 0
+	})
+	hoursleft := sim.hoursOfSlot(day)
+	for _, t := range tscp {
+		hoursleft = sim.burn(t, day, hoursleft, hoursleft)
+	}
+}
+
+// small reports whether the ticket is a small ticket by the parameters
+func (p *Params) small(t *ticket) bool {
+	return t.effort <= p.SmallThreshold
+}
+
+// burndownSmallBatchFirst burn down the small tickets oldest first with the
+// fraction of the hours reserved for them, then the large tickets oldest
+// first. Hours left go to the small tickets again.
+func burndownSmallBatchFirst(sim *simulation, day int) {
+	hoursleft := sim.hoursOfSlot(day)
+	smallhours := int(math.Round(float64(hoursleft) * sim.params.SmallFraction))
+	hoursleft -= smallhours
+	for _, t := range sim.tickets {
+		if sim.params.small(t) {
+			smallhours = sim.burn(t, day, smallhours, smallhours)
+		}
+	}
+	hoursleft += smallhours
+	for _, t := range sim.tickets {
+		if !sim.params.small(t) {
+			hoursleft = sim.burn(t, day, hoursleft, hoursleft)
+		}
+	}
+	for _, t := range sim.tickets {
+		hoursleft = sim.burn(t, day, hoursleft, hoursleft)
+	}
+}
+
+// policies the orders of tickets by name for the pull and work policies,
+// each compares the tickets a and b at day
+var policies = map[string]func(a, b *ticket, day int) bool{
+	"fifo": func(a, b *ticket, day int) bool {
+		return a.startday < b.startday
+	},
+	"lifo": func(a, b *ticket, day int) bool {
+		return a.startday > b.startday
+	},
+	"sjf": func(a, b *ticket, day int) bool {
+		return a.believed(day) < b.believed(day)
+	},
+	"edf": func(a, b *ticket, day int) bool {
+		return a.deadline < b.deadline
+	},
+}
+
+// policyEqual the work policy to work on each ticket max 2h per day
+const policyEqual = "equal"
+
+// sortByPolicy sort the tickets by the policy
+func (sim *simulation) sortByPolicy(ts []*ticket, policy string, day int) {
+	less := policies[policy]
+	sim.sortTickets(ts, day, func(a, b *ticket) bool {
+		return less(a, b, day)
+	})
+}
+
+// the tie-breaks of tickets equal by the order of a strategy
+const (
+	tieFifo     = "fifo"
+	tieLifo     = "lifo"
+	tieRandom   = "random"
+	tieSmallest = "smallest"
+)
+
+// tiebreaks the orders of tickets equal by the order of a strategy,
+// each compares the tickets a and b at day
+var tiebreaks = map[string]func(a, b *ticket, day int) bool{
+	tieFifo: func(a, b *ticket, day int) bool {
+		return a.seq < b.seq
+	},
+	tieLifo: func(a, b *ticket, day int) bool {
+		return a.seq > b.seq
+	},
+	tieRandom: func(a, b *ticket, day int) bool {
+		return a.tiekey < b.tiekey
+	},
+	tieSmallest: func(a, b *ticket, day int) bool {
+		return a.believed(day) < b.believed(day)
+	},
+}
+
+// sortTickets sort the tickets by less, tickets equal by less by their
+// stable rank with stable jitter, then by the tie-break of the parameters
+func (sim *simulation) sortTickets(ts []*ticket, day int,
+	less func(a, b *ticket) bool) {
+	tie := tiebreaks[sim.params.Tiebreak]
+	jitter := sim.params.StableJitter
+	sort.SliceStable(ts, func(i, j int) bool {
+		a, b := ts[i], ts[j]
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		if jitter && a.rank != b.rank {
+			return a.rank < b.rank
+		}
+		return tie(a, b, day)
+	})
+}
+
+// burndownAlternating burn down the oldest tickets first on odd days and the
+// shortest tickets first on even days
+func burndownAlternating(sim *simulation, day int) {
+	tscp := sim.copyTickets()
+	policy := "sjf"
+	if day%2 == 1 {
+		policy = "fifo"
+	}
+	sim.sortByPolicy(tscp, policy, day)
+	hoursleft := sim.hoursOfSlot(day)
+	for _, t := range tscp {
+		hoursleft = sim.burn(t, day, hoursleft, hoursleft)
+	}
+}
+
+// burn burn down a ticket, max for the given hours and return updated
+// hoursleft. Count a context switch if work moves away from an unfinished
+// ticket.
+func (sim *simulation) burn(t *ticket, day, hoursleft, hours int) int {
+	if day == t.startday && sim.params.ArrivalTime < 1 {
+		// only a part of the effort is workable on the arrival day
+		workable := int(math.Round(sim.params.ArrivalTime * float64(t.effort)))
+		if h := workable - (t.effort - t.current(day)); h < hours {
+			hours = int(math.Max(float64(h), 0))
+		}
+	}
+	if t.parallel < 1 {
+		// the serial part caps the hours per day
+		hours = max(min(hours, sim.parallelCap(t)-t.hoursOn(day)), 0)
+	}
+	if t.ramped < sim.params.RampCost && t.current(day) > 0 && !t.blocked(day) {
+		hoursleft, hours = sim.ramp(t, day, hoursleft, hours)
+	}
+	factor := t.learningFactor(day, sim.params.LearningRate)
+	left := t.burndownhours(day, hoursleft, hours, factor)
+	if left == hoursleft {
+		return left
+	}
+	sim.worked[day] += hoursleft - left
+	sim.record(t, day, hoursleft-left)
+	if sim.params.reworking() && t.current(day) == 0 {
+		sim.reopen(t, day)
+	}
+	last := sim.lastworked
+	if last != nil && last != t && last.remaining[sim.lastworkedday+1] > 0 {
+		sim.switches[day]++
+	}
+	sim.lastworked = t
+	sim.lastworkedday = day
+	return left
+}
+
+// ramp spend up to hours of hoursleft to start the ticket on day until its
+// ramp cost is spent, return the updated hoursleft and the hours left to work
+func (sim *simulation) ramp(t *ticket, day, hoursleft, hours int) (int, int) {
+	r := min(sim.params.RampCost-t.ramped, hours, hoursleft)
+	if r <= 0 {
+		return hoursleft, hours
+	}
+	if t.firstwork < 0 {
+		t.firstwork = day
+	}
+	t.ramped += r
+	sim.ramped += r
+	sim.worked[day] += r
+	sim.record(t, day, r)
+	return hoursleft - r, hours - r
+}
+
+// carry carry the remaining work of all tickets not burned down yet to the
+// next day
+func (sim *simulation) carry(day int) {
+	for _, t := range sim.tickets {
+		t.burndownhours(day, 0, 0, 1)
+	}
+}
+
+// burndownKanban pull tickets from the backlog into work by the pull policy
+// up to the WIP limit, work on the tickets in work by the work policy.
+// When a ticket is done the next ticket is pulled into work.
+func burndownKanban(sim *simulation, day int) {
+	inwork := make([]*ticket, 0, sim.params.WipLimit)
+	pulled := make(map[*ticket]bool, sim.params.WipLimit)
+	for _, t := range sim.inwork {
+		if t.current(day) > 0 {
+			inwork = append(inwork, t)
+			pulled[t] = true
+		}
+	}
+	backlog := make([]*ticket, 0)
+	for _, t := range sim.openTickets(day) {
+		if !pulled[t] {
+			backlog = append(backlog, t)
+		}
+	}
+	sim.sortByPolicy(backlog, sim.params.PullPolicy, day)
+	for len(inwork) < sim.params.WipLimit && len(backlog) > 0 {
+		inwork = append(inwork, backlog[0])
+		backlog = backlog[1:]
+	}
+	hoursleft := sim.hoursOfSlot(day)
+	if sim.params.WorkPolicy == policyEqual {
+		hourswork := 2
+		for _, t := range inwork {
+			hoursleft = sim.burn(t, day, hoursleft, t.capOn(day, hourswork))
+		}
+	} else {
+		sim.sortByPolicy(inwork, sim.params.WorkPolicy, day)
+	}
+	for _, t := range inwork {
+		hoursleft = sim.burn(t, day, hoursleft, hoursleft)
+	}
+	// pull a ticket from the backlog for each ticket done today
+	for hoursleft > 0 && len(backlog) > 0 {
+		done := 0
+		for _, t := range inwork {
+			if t.remaining[day+1] == 0 {
+				done++
+			}
+		}
+		if len(inwork)-done >= sim.params.WipLimit {
+			break
+		}
+		t := backlog[0]
+		backlog = backlog[1:]
+		inwork = append(inwork, t)
+		hoursleft = sim.burn(t, day, hoursleft, hoursleft)
+	}
+	sim.inwork = inwork
+	sim.carry(day)
+}
+
+// burndownCappedPull pull the oldest tickets into work up to the WIP limit,
+// work on each ticket in work at most the daily cap. When a ticket is done
+// the next ticket is pulled into work. Hours left are not used.
+func burndownCappedPull(sim *simulation, day int) {
+	daycap := sim.params.DailyCap
+	hoursleft := sim.hoursOfSlot(day)
+	inwork := make([]*ticket, 0, sim.params.WipLimit)
+	waiting := make([]*ticket, 0)
+	for _, t := range sim.openTickets(day) {
+		if t.firstwork >= 0 && len(inwork) < sim.params.WipLimit {
+			inwork = append(inwork, t)
+		} else {
+			waiting = append(waiting, t)
+		}
+	}
+	for len(inwork) < sim.params.WipLimit && len(waiting) > 0 {
+		inwork = append(inwork, waiting[0])
+		waiting = waiting[1:]
+	}
+	for _, t := range inwork {
+		hoursleft = sim.burn(t, day, hoursleft, t.capOn(day, daycap))
+	}
+	// pull a waiting ticket for each ticket done today
+	for hoursleft > 0 && len(waiting) > 0 {
+		open := 0
+		for _, t := range inwork {
+			if t.current(day) > 0 {
+				open++
+			}
+		}
+		if open >= sim.params.WipLimit {
+			break
+		}
+		t := waiting[0]
+		waiting = waiting[1:]
+		inwork = append(inwork, t)
+		hoursleft = sim.burn(t, day, hoursleft, t.capOn(day, daycap))
+	}
+	sim.carry(day)
+}
+
+// burndownWipHours pull the oldest tickets into work while the remaining
+// work of the tickets in work is below the WIP limit in hours, at least one.
+// Work on each ticket in work max 2h per day, then oldest first with the
+// hours left. When hours are left the next ticket is pulled into work.
+func burndownWipHours(sim *simulation, day int) {
+	hourswork := 2
+	hoursleft := sim.hoursOfSlot(day)
+	inwork := make([]*ticket, 0)
+	waiting := make([]*ticket, 0)
+	for _, t := range sim.openTickets(day) {
+		if t.firstwork >= 0 {
+			inwork = append(inwork, t)
+		} else {
+			waiting = append(waiting, t)
+		}
+	}
+	inprogress := func() int {
+		hours := 0
+		for _, t := range inwork {
+			hours += t.current(day)
+		}
+		return hours
+	}
+	for len(waiting) > 0 &&
+		(len(inwork) == 0 || inprogress() < sim.params.WipHours) {
+		inwork = append(inwork, waiting[0])
+		waiting = waiting[1:]
+	}
+	for _, t := range inwork {
+		hoursleft = sim.burn(t, day, hoursleft, t.capOn(day, hourswork))
+	}
+	for _, t := range inwork {
+		hoursleft = sim.burn(t, day, hoursleft, hoursleft)
+	}
+	for hoursleft > 0 && len(waiting) > 0 &&
+		inprogress() < sim.params.WipHours {
+		t := waiting[0]
+		waiting = waiting[1:]
+		inwork = append(inwork, t)
+		hoursleft = sim.burn(t, day, hoursleft, hoursleft)
+	}
+	sim.carry(day)
+}
+
+// burndownConwip keep the constant WIP of tickets in work, admit the oldest
+// waiting ticket as soon as one is done. Share the hours evenly among the
+// tickets in work until the hours are spent or no ticket is left. Record the
+// count of tickets in work.
+func burndownConwip(sim *simulation, day int) {
+	hoursleft := sim.hoursOfSlot(day)
+	inwork := make([]*ticket, 0, sim.params.Conwip)
+	waiting := make([]*ticket, 0)
+	for _, t := range sim.openTickets(day) {
+		if t.firstwork >= 0 {
+			inwork = append(inwork, t)
+		} else {
+			waiting = append(waiting, t)
+		}
+	}
+	admit := func() {
+		open := inwork[:0]
+		for _, t := range inwork {
+			if t.current(day) > 0 {
+				open = append(open, t)
+			}
+		}
+		inwork = open
+		for len(inwork) < sim.params.Conwip && len(waiting) > 0 {
+			inwork = append(inwork, waiting[0])
+			waiting = waiting[1:]
+		}
+	}
+	admit()
+	sim.realizedWip.add(float64(len(inwork)))
+	for hoursleft > 0 && len(inwork) > 0 {
+		share := max(hoursleft/len(inwork), 1)
+		before := hoursleft
+		for _, t := range inwork {
+			hoursleft = sim.burn(t, day, hoursleft, share)
+		}
+		admit()
+		if hoursleft == before {
+			break
+		}
+	}
+	sim.carry(day)
+}
+
+// burndownPullFifo pull the oldest tickets into work up to the WIP limit,
+// work on each ticket in work max 2h per day. When a ticket is done the next
+// ticket is pulled into work.
+func burndownPullFifo(sim *simulation, day int) {
+	hourswork := 2
+	hoursleft := sim.hoursOfSlot(day)
+	open := sim.openTickets(day)
+	inwork := open
+	if len(inwork) > sim.params.WipLimit {
+		inwork = open[:sim.params.WipLimit]
+	}
+	for _, t := range inwork {
+		hoursleft = sim.burn(t, day, hoursleft, t.capOn(day, hourswork))
+	}
+	for _, t := range inwork {
+		hoursleft = sim.burn(t, day, hoursleft, hoursleft)
+	}
+	// pull a waiting ticket for each ticket done today
+	slots := 0
+	for _, t := range inwork {
+		if t.remaining[day+1] == 0 {
+			slots++
+		}
+	}
+	for _, t := range open[len(inwork):] {
+		hours := 0
+		if slots > 0 && hoursleft > 0 {
+			hours = hoursleft
+			slots--
+		}
+		hoursleft = sim.burn(t, day, hoursleft, hours)
+		if hours > 0 && t.remaining[day+1] == 0 {
+			slots++
+		}
+	}
+}
+
+// simulationset the set of simulations
+type simulationset []simulation
+
+// NewSimulationset create the set of simulations
+func NewSimulationset(p *Params) simulationset {
+	sz := p.Days * 3 / 2 // some more size avoid reallocation
+	simset := make(simulationset, 0, len(strategies))
+	for _, st := range strategies {
+		if p.runs(st.id) {
+			simset = append(simset, NewSimulation(st, p, sz))
+		}
+	}
+	return simset
+}
+
+// runs reports whether the strategy with id is selected to run
+func (p *Params) runs(id string) bool {
+	if len(p.Strategies) == 0 {
+		return true
+	}
+	for _, s := range p.Strategies {
+		if s == id {
+			return true
+		}
+	}
+	return false
+}
+
+// find return the simulation of the strategy with id, false if none
+func (simset simulationset) find(id string) (simulation, bool) {
+	for _, s := range simset {
+		if s.id == id {
+			return s, true
+		}
+	}
+	return simulation{}, false
+}
+
+// throughputCv return the coefficient of variation of the tickets done per day,
+// lower is a more stable throughput
+func (sim simulation) throughputCv() float64 {
+	m, s := meanStdev(sim.completedPerDay())
+	return s / m
+}
+
+// lowestIndex return the index of the lowest of the values, NaN skipped, the
+// first on ties, -1 if all are NaN
+func lowestIndex(values []float64) int {
+	lowest := -1
+	for i, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		if lowest < 0 || v < values[lowest] {
+			lowest = i
+		}
+	}
+	return lowest
+}
+
+// verdict rank the strategies by mean leadtime, worst case leadtime and
+// stability of throughput and recommend a strategy for the offered load,
+// empty if there are less than two strategies to compare
+func (simset simulationset) verdict(rho float64) string {
+	if len(simset) < 2 {
+		return ""
+	}
+	places := simset[0].params.Precision
+	done := 0
+	for _, s := range simset {
+		n, _, _ := s.sumCompletionTimes()
+		done += n
+	}
+	if done == 0 {
+		return "Verdict: " + notAvailable + "\n"
+	}
+	// a strategy without done tickets has no mean, worst case and cv, NaN,
+	// it is skipped, some strategy has done tickets
+	means := make([]float64, len(simset))
+	worsts := make([]float64, len(simset))
+	cvs := make([]float64, len(simset))
+	for i, s := range simset {
+		means[i], _, _ = s.statsLeadTime()
+		worsts[i] = math.NaN()
+		if len(s.leadtimes()) > 0 {
+			worsts[i] = float64(s.percentile(100))
+		}
+		cvs[i] = s.throughputCv()
+	}
+	bestMean, bestWorst := lowestIndex(means), lowestIndex(worsts)
+	var buf bytes.Buffer
+	buf.WriteString("Verdict\n")
+	frmt := withPrecision("Shortest mean leadtime: %s %.2f days\n", places)
+	buf.WriteString(fmt.Sprintf(frmt, simset[bestMean].name, means[bestMean]))
+	buf.WriteString(fmt.Sprintf("Shortest worst case leadtime: %s %.0f days\n",
+		simset[bestWorst].name, worsts[bestWorst]))
+	if bestStable := lowestIndex(cvs); bestStable >= 0 {
+		frmt = withPrecision("Most stable throughput: %s cv %.2f\n", places)
+		buf.WriteString(fmt.Sprintf(frmt, simset[bestStable].name,
+			cvs[bestStable]))
+	} else {
+		buf.WriteString("Most stable throughput: " + notAvailable + "\n")
+	}
+	var rec string
+	switch {
+	case rho >= 1:
+		rec = "the system is overloaded, limit the intake or add capacity first"
+	case bestMean == bestWorst:
+		rec = fmt.Sprintf("use %s, best on mean and worst case",
+			simset[bestMean].name)
+	case rho >= 0.8:
+		rec = fmt.Sprintf("at high load use %s to bound the worst case",
+			simset[bestWorst].name)
+	default:
+		rec = fmt.Sprintf("at moderate load use %s for the shortest mean",
+			simset[bestMean].name)
+	}
+	frmt = withPrecision("Recommendation (offered load %.2f): %s\n", places)
+	buf.WriteString(fmt.Sprintf(frmt, rho, rec))
+	return buf.String()
+}
+
+// bracketReport compare the mean leadtime of the lowest WIP strategy swarm
+// with the highest WIP strategy equal working, empty if one is missing
+func (simset simulationset) bracketReport() string {
+	swarm, okSwarm := simset.find("swarm")
+	equal, okEqual := simset.find("equal")
+	if !okSwarm || !okEqual {
+		return ""
+	}
+	places := swarm.params.Precision
+	ms, _, _ := swarm.statsLeadTime()
+	me, _, _ := equal.statsLeadTime()
+	frmt := "Mean leadtime from WIP 1 (%s): %s to max WIP (%s): %s\n"
+	return fmt.Sprintf(frmt, swarm.name, orNA("%.2f", ms, places), equal.name,
+		orNA("%.2f", me, places))
+}
+
+// wipHoursReport compare the mean leadtime of the WIP limit by hours hpull
+// with the WIP limit by count pull, empty if one is missing
+func (simset simulationset) wipHoursReport() string {
+	hours, okHours := simset.find("hpull")
+	count, okCount := simset.find("pull")
+	if !okHours || !okCount {
+		return ""
+	}
+	places := hours.params.Precision
+	mh, _, _ := hours.statsLeadTime()
+	mc, _, _ := count.statsLeadTime()
+	frmt := "Mean leadtime with WIP limit %d h (%s): %s, %d tickets (%s): %s\n"
+	return fmt.Sprintf(frmt, hours.params.WipHours, hours.name,
+		orNA("%.2f", mh, places), count.params.WipLimit, count.name,
+		orNA("%.2f", mc, places))
+}
+
+// conwipReport compare the mean leadtime of the constant WIP conwip with
+// the WIP limit by count pull, empty if one is missing
+func (simset simulationset) conwipReport() string {
+	conwip, okConwip := simset.find("conwip")
+	pull, okPull := simset.find("pull")
+	if !okConwip || !okPull {
+		return ""
+	}
+	places := conwip.params.Precision
+	mc, _, _ := conwip.statsLeadTime()
+	mp, _, _ := pull.statsLeadTime()
+	frmt := "Mean leadtime with constant WIP %d (%s): %s, WIP limit %d (%s): %s\n"
+	return fmt.Sprintf(frmt, conwip.params.Conwip, conwip.name,
+		orNA("%.2f", mc, places), pull.params.WipLimit, pull.name,
+		orNA("%.2f", mp, places))
+}
+
+func (simset simulationset) String() string {
+	texts := make([]string, len(simset))
+	for i, s := range simset {
+		texts[i] = s.String()
+	}
+	if useColor && len(simset) > 1 {
+		texts = simset.colorize(texts)
+	}
+	var buf bytes.Buffer
+	for _, text := range texts {
+		buf.WriteString(fmt.Sprintln(text))
+	}
+	return buf.String()
+}
+
+// addTickets add the tickets to each simulation
+func (simset simulationset) addTickets(ts []*ticket) simulationset {
 	for i, s := range simset {
 		simset[i] = s.addTickets(ts)
 	}
@@ -310,63 +1997,1091 @@ func (simset simulationset) addTickets(ts []*ticket) simulationset {
 
 // burndown the tickets in each simulation
 func (simset simulationset) burndown(day int) {
-	for _, s := range simset {
-		s.burndownaday(&s, day)
+	for i := range simset {
+		s := &simset[i]
+		if s.params.CancelRate > 0 {
+			s.cancel(day)
+		}
+		for s.slot = 0; s.slot < s.params.Resolution; s.slot++ {
+			if s.params.ExpediteRate > 0 {
+				s.expedited = s.burnExpedites(day)
+			}
+			s.burndownaday(s, day)
+		}
+	}
+}
+
+// run add the arrivals of each day and burn down the tickets in each
+// simulation. If the context is cancelled stop at the next day and return the simulations
+// up to the last day simulated and the error of the context.
+// If a simulation verifies its invariants stop at the first violation and
+// return the error. If the simulations drain but do not within the days to
+// drain return the error of drainError.
+func (simset simulationset) run(ctx context.Context,
+	arrivals [][]*ticket) (simulationset, error) {
+	return simset.runRouted(ctx, arrivals,
+		func(simset simulationset, _ int, tickets []*ticket) simulationset {
+			return simset.addTickets(tickets)
+		})
+}
+
+// runRouted run the simulations like run, add adds the new tickets of a day
+// to the simulations
+func (simset simulationset) runRouted(ctx context.Context, arrivals [][]*ticket,
+	add func(simset simulationset, day int, tickets []*ticket) simulationset) (
+	simulationset, error) {
+	days := len(arrivals)
+	for d, tickets := range arrivals {
+		if err := ctx.Err(); err != nil {
+			return simset, err
+		}
+		if simset.drained(d) {
+			return simset, nil
+		}
+		simset = add(simset, d, tickets)
+		// burndown on all days except last day
+		if d < days-1 {
+			simset.burndown(d)
+			for _, s := range simset {
+				if !s.params.Verify {
+					continue
+				}
+				if err := s.verify(d); err != nil {
+					return simset, err
+				}
+			}
+		}
+		for i := range simset {
+			simset[i].lastday = d
+		}
 	}
+	return simset, simset.drainError(days - 1)
 }
 
-// simdays read number of days to simulate from args, use default if none is given,
-// log fatal if not readable
-func simdays() int {
-	a := os.Args
-	if len(a) <= 1 {
-		return maxPrint // the default
+// drainError return the error for simulations to drain with open tickets at
+// the last day, naming the open tickets and hours per strategy, nil if none
+func (simset simulationset) drainError(last int) error {
+	var open []string
+	rho := 0.0
+	for _, s := range simset {
+		if !s.params.Drain {
+			continue
+		}
+		ts := s.openTickets(last)
+		if len(ts) == 0 {
+			continue
+		}
+		hours := 0
+		for _, t := range ts {
+			hours += t.current(last)
+		}
+		open = append(open, fmt.Sprintf("%s %d tickets %d h", s.id, len(ts),
+			hours))
+		rho = offeredLoad(s.params)
+	}
+	if len(open) == 0 {
+		return nil
 	}
-	d, err := strconv.Atoi(a[1])
-	if err != nil || len(a) > 2 {
-		log.Fatal("usage: " + a[0] + " <n>")
+	cause := "raise -drain-days"
+	if rho >= 1 {
+		cause = "offered load exceeds capacity"
 	}
-	return d
+	frmt := withPrecision("%w in %d days, offered load %.2f, %s; open: %s",
+		simset[0].params.Precision)
+	return fmt.Errorf(frmt, errNotDrained, simset[0].params.drainDays(), rho,
+		cause, strings.Join(open, ", "))
 }
 
-func printSimulatedDataHeader(days int) {
-	fmt.Println("Simulating", days, "days")
-	if days <= maxPrint {
-		header := "day, count, effort, ticket{startday leadtime endday effort" +
-			" [remaining/day]}"
-		fmt.Println(header)
+// errNotDrained the error wrapped by drainError
+var errNotDrained = errors.New("system did not drain")
+
+// warnDrain log the error of simulations that did not drain as a warning of
+// the report name and return nil, return any other error. The reports that
+// rerun the simulations, e.g. for more seeds, report the runs that drained
+// too late like those that drained.
+func warnDrain(name string, err error) error {
+	if errors.Is(err, errNotDrained) {
+		log.Print(name, ": warning: ", err)
+		return nil
 	}
+	return err
 }
 
-func main() {
-	days := simdays()
-	printSimulatedDataHeader(days)
-	meanNewPerDay := 1.0
-	stddevNewPerDay := 1.0
+// drained reports whether all simulations drain, have no arrivals after day
+// and no open tickets at day
+func (simset simulationset) drained(day int) bool {
+	for _, s := range simset {
+		if !s.params.Drain || day < s.params.Days || len(s.openTickets(day)) > 0 {
+			return false
+		}
+	}
+	return len(simset) > 0
+}
+
+// Run simulate all strategies with the parameters on the arrivals.
+// If the context is cancelled the simulation stops at the next day,
+// the partial results up to the last day simulated are returned
+// together with the error of the context.
+func Run(ctx context.Context, p *Params, arrivals [][]*ticket) (simulationset, error) {
+	return NewSimulationset(p).run(ctx, arrivals)
+}
+
+// createArrivals create the new tickets for each day,
+// return the tickets per day, the count of tickets and the sum of effort.
+// With split streams the efforts and costs of delay are drawn from a stream
+// seeded from the seed of the parameters, the counts only from the sampler.
+// With the interarrival model the time between two tickets is exponential
+// with mean 1/MeanNewPerDay days, the count per day then is poisson.
+// The new tickets of few days are printed to out, io.Discard prints nothing.
+func createArrivals(p *Params, smp *sampler, out io.Writer) ([][]*ticket, int, int) {
+	arrivals := make([][]*ticket, p.horizon())
 	sumCount := 0
-	meanEffortNew := 6.0
-	stddevEffortNew := 4.0
-	minEffort := 1
 	sumEffort := 0
-	simset := NewSimulationset(days)
-	for d := 0; d < days; d++ {
-		count := randomValueInt(meanNewPerDay, stddevNewPerDay, 0)
+	next := 0.0 // time of the next arrival in days for the interarrival model
+	esmp := smp // sampler of the efforts
+	if p.SplitStreams {
+		esmp = newSampler(rand.New(rand.NewSource(p.Seed+9)), p)
+	}
+	// align the columns of the new tickets printed
+	tw := tabwriter.NewWriter(out, 0, 0, 1, ' ', 0)
+	defer tw.Flush()
+	if p.ArrivalModel == arrivalInterarrival {
+		next = smp.rng.ExpFloat64() / p.MeanNewPerDay
+	}
+	for d := 0; d < p.Days; d++ {
+		count := 0
+		if p.ArrivalModel == arrivalInterarrival {
+			for next < float64(d+1) {
+				count++
+				next += smp.rng.ExpFloat64() / p.MeanNewPerDay
+			}
+		} else if p.ArrivalModel == arrivalBatch {
+			if d == 0 {
+				count = p.BatchSize
+			}
+		} else {
+			count = smp.randomValueInt(p.MeanNewPerDay, p.StddevNewPerDay, 0)
+		}
+		if p.MaxTickets > 0 && sumCount+count > p.MaxTickets {
+			count = p.MaxTickets - sumCount
+		}
 		sumCount += count
-		tickets, effort := createTicketsForDay(d, days, count,
-			meanEffortNew, stddevEffortNew, minEffort)
-		simset = simset.addTickets(tickets)
-		// burndown on all days except last day
-		if d < days-1 {
-			simset.burndown(d)
+		tickets, effort := createTicketsForDay(esmp, p, d, count, tw)
+		for i, t := range tickets {
+			t.seq = sumCount - count + i
 		}
+		arrivals[d] = tickets
 		sumEffort += effort
+	}
+	decorateArrivals(p, arrivals)
+	return arrivals, sumCount, sumEffort
+}
+
+// decorateArrivals add the dependencies, tie keys and expedites to the
+// tickets of the arrivals as the parameters ask for
+func decorateArrivals(p *Params, arrivals [][]*ticket) {
+	if p.Dependencies > 0 {
+		addDependencies(p, arrivals)
+	}
+	if p.Tiebreak == tieRandom {
+		addTiekeys(p, arrivals)
+	}
+	if p.ExpediteRate > 0 {
+		addExpedites(p, arrivals)
+	}
+	if p.StableJitter {
+		addRanks(p, arrivals)
+	}
+	if p.EffortDiscovery > 0 {
+		addMisestimates(p, arrivals)
+	}
+	if p.Parallel < 1 {
+		setParallel(p, arrivals)
+	}
+}
+
+// addTiekeys draw the random keys to break ties of the tickets of the
+// arrivals, seeded from the seed of the parameters
+func addTiekeys(p *Params, arrivals [][]*ticket) {
+	rng := rand.New(rand.NewSource(p.Seed + 6))
+	for _, tickets := range arrivals {
+		for _, t := range tickets {
+			t.tiekey = rng.Float64()
+		}
+	}
+}
+
+// addRanks give each ticket of the arrivals its stable random rank, a hash
+// of the seed of the parameters and the sequence of the ticket. The rank of
+// a ticket does not depend on the draws for other tickets.
+func addRanks(p *Params, arrivals [][]*ticket) {
+	for _, tickets := range arrivals {
+		for _, t := range tickets {
+			t.rank = splitmix(uint64(p.Seed) ^ uint64(t.seq)*0x9e3779b97f4a7c15)
+		}
+	}
+}
+
+// splitmix return the splitmix64 hash of x, spreading close values apart
+func splitmix(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+	x = (x ^ x>>27) * 0x94d049bb133111eb
+	return x ^ x>>31
+}
+
+// wipSweep rerun the pull strategy on the arrivals for each WIP limit
+// from lowest to highest, return a table of WIP limit, mean leadtime
+// and throughput. If the context is cancelled return the error.
+func wipSweep(ctx context.Context, p *Params, arrivals [][]*ticket,
+	lowest, highest int) (string, error) {
+	sz := p.Days * 3 / 2 // some more size avoid reallocation
+	simset := make(simulationset, 0, highest-lowest+1)
+	for w := lowest; w <= highest; w++ {
+		pw := *p
+		pw.WipLimit = w
+		st := strategy{"pull", fmt.Sprint("Pull oldest first, WIP ", w), "",
+			burndownPullFifo}
+		simset = append(simset, NewSimulation(st, &pw, sz))
+	}
+	simset, err := simset.run(ctx, arrivals)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	buf.WriteString("WIP sweep of pull oldest first\n")
+	buf.WriteString("# wip mean-leadtime throughput\n")
+	for _, s := range simset {
+		m, _, _ := s.statsLeadTime()
+		buf.WriteString(fmt.Sprintf(withPrecision("%d %.2f %.2f\n",
+			p.Precision), s.params.WipLimit, m, s.throughput()))
+	}
+	return buf.String(), nil
+}
+
+// parseCapacityChange read a capacity change day:hours, the day at least 1 as
+// day 0 means no change
+func parseCapacityChange(c string) (int, float64, error) {
+	parts := strings.Split(c, ":")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("capacity change %q not of form day:hours", c)
+	}
+	day, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	if day < 1 {
+		return 0, 0, fmt.Errorf("capacity change day %d must be at least 1,"+
+			" use -capacity from day 0 on", day)
+	}
+	hours, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return day, hours, nil
+}
+
+// parseRange read a range lowest:highest of positive ints
+func parseRange(r string) (int, int, error) {
+	parts := strings.Split(r, ":")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("range %q not of form lowest:highest", r)
+	}
+	lowest, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	highest, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	if lowest < 1 || highest < lowest {
+		return 0, 0, fmt.Errorf("range %q not 1 <= lowest <= highest", r)
+	}
+	return lowest, highest, nil
+}
+
+// the output formats
+const (
+	formatText     = "text"     // readable report
+	formatInflux   = "influx"   // InfluxDB line protocol of the metrics per day
+	formatScatter  = "scatter"  // effort and leadtime of the done tickets
+	formatMarkdown = "markdown" // table of the metrics per strategy
+	formatEffort   = "effort"   // done tickets per day and effort bucket
+)
+
+// defaultStartTime the time of the first day of the time series by default,
+// fixed for reproducible output
+const defaultStartTime = "2021-01-01T00:00:00Z"
+
+// options the options of the command line besides the parameters
+type options struct {
+	wipSweep     string    // WIP sweep range lowest:highest, empty if none
+	dumpTickets  string    // file to write all ticket records to, empty if none
+	format       string    // the output format
+	startTime    time.Time // the time of the first day for time series
+	sensitivity  int       // count of most influential tickets to report, 0 off
+	manifest     bool      // print the manifest of the run as header
+	manifestFile string    // file to write the manifest of the run to
+	ensemble     string    // strategy id to rerun with jittered parameters
+	ensembleCfg  ensembleConfig
+	scenario     string // JSON file with the parameters, empty if none
+	preset       string // name of the scenario preset, empty if none
+	compare      string // two scenario files a,b to compare, empty if none
+	wipTune      string // WIP range lowest:highest to tune, empty if none
+	noColor      bool   // no colors even on a terminal
+	dumpRng      string // file to log the random draws to, empty if none
+	summary      string // file to write the summary CSV to, empty if none
+	wipHistogram bool   // print the fraction of days at each WIP level
+	checksum     bool   // print only the checksum of the leadtimes
+	minCapacity  string // strategy id to find the capacity for the SLA
+	seeds        int    // count of seeds of the tournament, 0 off
+	dumpOpen     string // file to write the open tickets per day, empty if none
+	sortBy       string // metric to sort the strategies by, empty if none
+	tickets      string // CSV file with the tickets to simulate, empty if none
+	grid         string // ranges of the parameters to run, empty if none
+	gridFile     string // file to write the results of the grid to
+	memstats     bool   // print the memory used after the run to stderr
+	weights      string // weights of the metrics of the score card, empty if none
+	sample       int    // count of tickets to sample for details, 0 off
+	efficiency   bool   // print the mean leadtimes as multiple of the optimum
+}
+
+// usage print the usage of the subcommand name with its flags, without a
+// subcommand with all flags and the registered strategies
+func usage(name string) {
+	out := flag.CommandLine.Output()
+	p := NewParams()
+	if c, ok := findCommand(name); ok {
+		fmt.Fprintf(out, "Usage: %s %s [flags] %s[days]\n\n%s.\n\n", os.Args[0],
+			name, c.args+strings.Repeat(" ", min(len(c.args), 1)),
+			c.description)
+		fmt.Fprintln(out, "Flags:")
+		flag.PrintDefaults()
+		return
+	}
+	fmt.Fprintf(out, "Usage: %s [flags] [days]\n\n", os.Args[0])
+	fmt.Fprintln(out, "Simulate the leadtime of tickets under each scheduling strategy")
+	fmt.Fprintf(out, "for days, default %d. Tickets arrive with mean %.1f per day,\n",
+		p.Days, p.MeanNewPerDay)
+	fmt.Fprintf(out, "have a mean effort of %.1f h, the team works %.1f h per day.\n",
+		p.MeanEffortNew, p.Capacity)
+	fmt.Fprintf(out, "Details of each ticket are printed for at most %d days.\n\n",
+		maxPrint)
+	usageCommands(out, os.Args[0])
+	fmt.Fprintln(out, "\nFlags:")
+	flag.PrintDefaults()
+	fmt.Fprintln(out, "\nStrategies:")
+	listStrategies(out)
+}
+
+// usageError print the error and the usage, exit with status 2
+// like the flag package
+func usageError(err string) {
+	fmt.Fprintln(flag.CommandLine.Output(), err)
+	flag.Usage()
+	os.Exit(2)
+}
 
+// parseArgs read the parameters and options from the command line,
+// use defaults if none are given, exit with usage if not readable.
+func parseArgs() (Params, options) {
+	opts := options{}
+	p := NewParams()
+	name, args := splitCommand(os.Args[1:])
+	if c, ok := findCommand(name); ok {
+		// the flags of the simulation and of the mode only
+		flag.CommandLine = flag.NewFlagSet(os.Args[0]+" "+name,
+			flag.ExitOnError)
+		if c.flags != nil {
+			c.flags(&opts)
+		}
+	} else {
+		// the flags of the analysis modes, the subcommands take them as
+		// arguments
+		flag.StringVar(&opts.wipSweep, "wip-sweep", "",
+			"rerun the pull strategy for each WIP limit `lowest:highest`")
+		flag.StringVar(&opts.ensemble, "ensemble", "",
+			"rerun the `strategy` with jittered WIP limit and capacity on the same"+
+				" arrivals")
+		flag.StringVar(&opts.compare, "compare", "",
+			"run the strategies with the scenario files `a.json,b.json` over the"+
+				" parameters and print the change of the metrics from a to b")
+		for _, c := range commands {
+			if c.flags != nil {
+				c.flags(&opts)
+			}
+		}
+	}
+	flag.Usage = func() { usage(name) }
+	flag.CommandLine.Usage = flag.Usage
+	flag.Int64Var(&p.Seed, "seed", 0,
+		"seed of the random generator, 0 seeds from the clock")
+	flag.BoolVar(&p.SplitStreams, "split-streams", false,
+		"draw the arrival counts and the efforts from two streams derived from"+
+			" the seed, changing one distribution keeps the other")
+	flag.IntVar(&p.WipLimit, "wip", p.WipLimit,
+		"maximum `tickets` in work for the pull and kanban strategies")
+	flag.IntVar(&p.StarveDays, "starve", p.StarveDays,
+		"report tickets waiting more than `days` for the first work, 0 off")
+	flag.IntVar(&p.SlaDays, "sla", 0,
+		"report the compliance with a leadtime of at most `days`, 0 off")
+	flag.Float64Var(&p.SlaPercent, "sla-percent", p.SlaPercent,
+		"`percentage` of tickets to meet the leadtime of -sla")
+	flag.IntVar(&p.Bootstrap, "bootstrap", 0,
+		"report 95% confidence intervals of mean and median leadtime of the done"+
+			" tickets from `count` bootstrap resamples, 0 off")
+	flag.BoolVar(&p.Verify, "verify", false,
+		"check after each burndown that no remaining work is negative")
+	flag.Float64Var(&p.DueFactor, "due-factor", p.DueFactor,
+		"allowed leadtime of a ticket as `multiple` of the days of its effort")
+	flag.StringVar(&p.ArrivalModel, "arrival-model", p.ArrivalModel,
+		"`model` of arrivals by gaussian count per day (daily), exponential time between"+
+			" tickets (interarrival) or all at day 0 (batch)")
+	flag.Float64Var(&p.LearningRate, "learning-rate", 0,
+		"more effort burned per hour for each day in a row on a ticket,"+
+			" `fraction` 0.1 for 10%, at most 50%")
+	flag.StringVar(&p.PullPolicy, "pull-policy", p.PullPolicy,
+		"kanban `order` to pull tickets into work fifo, lifo, sjf or edf")
+	flag.StringVar(&p.WorkPolicy, "work-policy", p.WorkPolicy,
+		"kanban `order` to work on tickets in work fifo, lifo, sjf, edf or equal")
+	flag.StringVar(&p.Rounding, "rounding", p.Rounding,
+		"`mode` to round random counts and efforts round, floor, ceil or"+
+			" stochastic")
+	flag.BoolVar(&p.Resample, "resample", false,
+		"redraw random counts and efforts below the minimum instead of clamping")
+	flag.Float64Var(&p.Capacity, "capacity", p.Capacity,
+		"working `hours` per day, a fraction accrues over the days")
+	flag.IntVar(&p.Resolution, "resolution", p.Resolution,
+		"`count` of burndowns per day, the strategies reprioritize each,"+
+			" 8 is hourly at 8 h capacity")
+	flag.Float64Var(&p.Overhead, "overhead", 0,
+		"fixed `hours` per day lost for meetings and admin before ticket work")
+	flag.BoolVar(&opts.manifest, "manifest", false,
+		"print a manifest with version, parameters and ticket hash as header")
+	flag.StringVar(&opts.manifestFile, "manifest-file", "",
+		"write the manifest of the run as JSON to `file`")
+	flag.StringVar(&opts.format, "format", formatText,
+		"output `format` text, influx (line protocol of the metrics per day),"+
+			" scatter (effort and leadtime of the done tickets), markdown"+
+			" (table of the metrics per strategy) or effort (done tickets per"+
+			" day and effort bucket)")
+	startTime := flag.String("start-time", defaultStartTime,
+		"RFC3339 `time` of the first day for the influx format")
+	flag.StringVar(&opts.scenario, "scenario", "",
+		"read the parameters and the format from the JSON `file`, flags"+
+			" override them")
+	flag.IntVar(&p.SmallThreshold, "small-threshold", p.SmallThreshold,
+		"maximum effort in `hours` of a small ticket")
+	flag.Float64Var(&p.SmallFraction, "small-capacity-fraction",
+		p.SmallFraction,
+		"`fraction` of the hours reserved for small tickets by the small strategy")
+	flag.StringVar(&opts.preset, "scenario-preset", "",
+		"set the parameters of the `preset` bigbatch (all tickets at day 0)")
+	flag.IntVar(&p.BatchSize, "batch-size", p.BatchSize,
+		"`count` of tickets arriving at day 0 with -arrival-model=batch")
+	flag.Float64Var(&p.IntakeFeedback, "intake-feedback", 0,
+		"reject new tickets of each strategy to reduce the arrival rate to"+
+			" mean / (1 + `coefficient` * open tickets), 0 off")
+	flag.BoolVar(&p.Trace, "trace", false,
+		"record and print the hours worked per ticket and day of each strategy")
+	flag.Float64Var(&p.ArrivalTime, "arrival-time", p.ArrivalTime,
+		"`fraction` of the effort of a ticket workable on its arrival day,"+
+			" the rest from the next day")
+	flag.IntVar(&p.MaxTickets, "max-tickets", 0,
+		"stop creating tickets after `count` tickets, 0 no limit")
+	flag.BoolVar(&p.Drain, "drain", false,
+		"simulate after the days until all tickets are done, at most -drain-days"+
+			" more, an error if they are not")
+	flag.IntVar(&p.DrainDays, "drain-days", 0,
+		"maximum `days` to simulate after the days with -drain, 0 as many as"+
+			" the days")
+	flag.Float64Var(&p.Dependencies, "dependencies", 0,
+		"`probability` a ticket depends on one of the 10 tickets created before,"+
+			" it is not worked before that is done, 0 off")
+	flag.BoolVar(&opts.noColor, "no-color", false,
+		"do not color the best and worst metrics on a terminal")
+	flag.IntVar(&p.Teams, "teams", p.Teams,
+		"simulate each strategy also with `count` teams, each with the capacity")
+	flag.StringVar(&p.Routing, "routing", p.Routing,
+		"`rule` to route new tickets to the teams round-robin, least-loaded or"+
+			" random")
+	flag.StringVar(&p.Tiebreak, "tiebreak", p.Tiebreak,
+		"`order` of tickets equal by a strategy fifo, lifo, random or smallest")
+	flag.IntVar(&p.WipHours, "wip-hours", p.WipHours,
+		"limit of the remaining work in `hours` of the tickets in work to pull"+
+			" another one for strategy hpull")
+	flag.IntVar(&p.DailyCap, "daily-cap", p.DailyCap,
+		"maximum `hours` per ticket and day of the capped pull strategy")
+	flag.Float64Var(&p.ExpediteRate, "expedite-rate", 0,
+		"`probability` per day of an expedite ticket preempting all other work"+
+			" until done, 0 off")
+	flag.IntVar(&p.ExpediteEffort, "expedite-effort", p.ExpediteEffort,
+		"effort in `hours` of an expedite ticket")
+	flag.IntVar(&p.Precision, "precision", p.Precision,
+		"decimal `places` of the metrics in the text output")
+	flag.Float64Var(&p.CancelRate, "cancel-rate", 0,
+		"`probability` per day an open ticket is cancelled, its work done wasted,"+
+			" 0 off")
+	flag.Float64Var(&p.Parallel, "parallel", p.Parallel,
+		"parallelizable `fraction` of the effort of a ticket, the serial rest caps"+
+			" the hours per ticket and day by Amdahl's law")
+	flag.Float64Var(&p.AwsjfRemaining, "awsjf-remaining", p.AwsjfRemaining,
+		"`exponent` of the remaining work in the weight of awsjf")
+	flag.Float64Var(&p.AwsjfAge, "awsjf-age", p.AwsjfAge,
+		"`exponent` of the days open in the weight of awsjf")
+	flag.StringVar(&p.EffortModel, "effort-model", p.EffortModel,
+		"`model` of the effort of a ticket, gaussian (normal) or exponential"+
+			" with the mean as standard deviation (exponential)")
+	flag.Float64Var(&p.EffortDiscovery, "effort-discovery", 0,
+		"standard deviation of the misestimate of the effort at arrival as"+
+			" `fraction` of the effort, discovered while working, 0 off")
+	flag.BoolVar(&p.StableJitter, "stable-jitter", false,
+		"break ties of tickets equal by a strategy by a stable random rank of"+
+			" each ticket before -tiebreak, the same in all strategies")
+	flag.Func("worker-availability",
+		"comma separated `fractions` of the capacity per worker, each one or"+
+			" 7 for the days of a week separated by /, e.g. 1,0.5/0.5/0.5/0.5/0.5/0/0",
+		func(s string) error {
+			var err error
+			p.Availability, err = parseAvailability(s)
+			return err
+		})
+	flag.StringVar(&opts.sortBy, "sort-by", "",
+		"print the strategies best first by `metric` mean, p95 or throughput,"+
+			" default in registration order")
+	flag.IntVar(&p.Conwip, "conwip", p.Conwip,
+		"constant count of `tickets` in work of the conwip strategy")
+	flag.IntVar(&p.MaxWait, "max-wait", p.MaxWait,
+		"`days` open after which the maxwait strategy promotes a ticket")
+	flag.IntVar(&p.RampCost, "ramp-cost", 0,
+		"`hours` to understand a ticket the first time it is worked, before"+
+			" its effort is burned down")
+	flag.BoolVar(&opts.wipHistogram, "wip-histogram", false,
+		"print per strategy the percent of days at each count of open tickets")
+	flag.Float64Var(&p.Rework, "rework", 0,
+		"`probability` a done ticket is reopened with half its effort")
+	flag.Float64Var(&p.ReworkWip, "rework-wip-coefficient", 0,
+		"added rework `probability` per open ticket at the completion")
+	flag.Func("effort-quantize",
+		"comma separated allowed efforts in `hours`, each effort is snapped to"+
+			" the nearest, e.g. 1,2,4,8",
+		func(s string) error {
+			p.EffortQuantize = nil
+			for _, e := range strings.Split(s, ",") {
+				h, err := strconv.Atoi(e)
+				if err != nil {
+					return err
+				}
+				p.EffortQuantize = append(p.EffortQuantize, h)
+			}
+			return nil
+		})
+	flag.Func("capacity-change",
+		"`day:hours` working hours per day from the day on, e.g. a team member"+
+			" leaves",
+		func(s string) error {
+			var err error
+			p.CapacityDay, p.CapacityAfter, err = parseCapacityChange(s)
+			return err
+		})
+	flag.Func("strategies",
+		"comma separated `ids` of the strategies to run, default all",
+		func(s string) error {
+			p.Strategies = strings.Split(s, ",")
+			return nil
+		})
+	flag.CommandLine.Parse(args)
+	if opts.preset != "" {
+		preset, ok := presets[opts.preset]
+		if !ok {
+			usageError("scenario-preset: unknown preset " + opts.preset)
+		}
+		preset(&p)
+	}
+	if opts.scenario != "" {
+		if err := readScenario(opts.scenario, &p, &opts.format); err != nil {
+			log.Fatal("scenario: ", err)
+		}
+	}
+	if opts.preset != "" || opts.scenario != "" {
+		// parse again, the flags override the preset and the scenario
+		flag.CommandLine.Parse(args)
+	}
+	a := flag.Args()
+	if c, ok := findCommand(name); ok {
+		if len(a) < c.count() {
+			usageError(name + " needs the arguments " + c.args)
+		}
+		c.set(&opts, a[:c.count()])
+		a = a[c.count():]
+		if c.print != nil {
+			c.print(os.Stdout)
+			os.Exit(0)
+		}
+	}
+	if len(a) > 1 {
+		usageError("too many arguments, flags must precede the days")
+	}
+	if len(a) == 1 {
+		d, err := strconv.Atoi(a[0])
+		if err != nil || d < 1 {
+			usageError("days must be a positive number: " + a[0])
+		}
+		p.Days = d
+	}
+	if p.Days == 0 && p.ArrivalModel == arrivalBatch {
+		p.Days = batchDays(&p)
 	}
+	switch opts.format {
+	case formatText, formatInflux, formatScatter, formatMarkdown, formatEffort:
+	default:
+		log.Fatal("format must be text, influx, scatter, markdown or effort")
+	}
+	var err error
+	opts.startTime, err = time.Parse(time.RFC3339, *startTime)
+	if err != nil {
+		log.Fatal("start-time: ", err)
+	}
+	if err := p.Validate(); err != nil {
+		usageError(err.Error())
+	}
+	if opts.ensemble != "" {
+		if _, ok := findStrategy(opts.ensemble); !ok {
+			usageError("ensemble: unknown strategy " + opts.ensemble)
+		}
+		if opts.ensembleCfg.runs < 1 {
+			usageError("ensemble-runs must be at least 1")
+		}
+	}
+	if opts.minCapacity != "" {
+		if _, ok := findStrategy(opts.minCapacity); !ok {
+			usageError("min-capacity: unknown strategy " + opts.minCapacity)
+		}
+		if p.SlaDays == 0 {
+			usageError("min-capacity needs the -sla")
+		}
+	}
+	if opts.compare != "" && len(strings.Split(opts.compare, ",")) != 2 {
+		usageError("compare must be two scenario files a.json,b.json")
+	}
+	if _, ok := sortKeys[opts.sortBy]; opts.sortBy != "" && !ok {
+		usageError("sort-by must be mean, p95 or throughput")
+	}
+	if opts.grid != "" {
+		if _, err := parseGrid(opts.grid); err != nil {
+			usageError("grid: " + err.Error())
+		}
+	}
+	if opts.weights != "" {
+		if _, err := parseWeights(opts.weights); err != nil {
+			usageError("weights: " + err.Error())
+		}
+	}
+	if opts.seeds < 0 {
+		usageError("seeds must not be negative")
+	}
+	if opts.sample < 0 {
+		usageError("sample-tickets must not be negative")
+	}
+	if opts.sensitivity < 0 {
+		usageError("sensitivity must not be negative")
+	}
+	if p.Seed == 0 {
+		p.Seed = time.Now().UnixNano()
+	}
+	return p, opts
+}
+
+// offeredLoad return the mean effort of new tickets per day
+// divided by the effective capacity per day
+func offeredLoad(p *Params) float64 {
+	return p.MeanNewPerDay * p.MeanEffortNew / p.effectiveCapacity()
+}
+
+// printStability print the offered load, warn if the system is overloaded
+func printStability(p *Params) {
+	rho := offeredLoad(p)
+	fmt.Printf(withPrecision("Offered load: %.2f\n", p.Precision), rho)
+	if rho >= 1 {
+		fmt.Println("Warning: offered load >= 1, the backlog grows without bound")
+	}
+}
+
+func printSimulatedDataHeader(days int, seed int64) {
+	fmt.Println("Simulating", days, "days, seed", seed)
+	if days <= maxPrint {
+		header := "day, count, effort, ticket{startday leadtime endday effort" +
+			" [remaining/day]}"
+		fmt.Println(header)
+	}
+}
+
+// meanStdev return mean and standard deviation of the values
+func meanStdev(values []int) (float64, float64) {
+	var w welford
+	for _, v := range values {
+		w.add(float64(v))
+	}
+	return w.mean(), w.stdev()
+}
+
+// samplingReport compare mean and standard deviation of the generated ticket
+// counts per day and efforts with the requested ones. Clamping at the lowest
+// value shifts the mean up.
+func samplingReport(p *Params, arrivals [][]*ticket) string {
+	places := p.Precision
+	counts := make([]int, len(arrivals))
+	efforts := make([]int, 0, len(arrivals))
+	for d, tickets := range arrivals {
+		counts[d] = len(tickets)
+		for _, t := range tickets {
+			efforts = append(efforts, t.effort)
+		}
+	}
+	stddevCount := p.StddevNewPerDay
+	if p.ArrivalModel == arrivalInterarrival {
+		stddevCount = math.Sqrt(p.MeanNewPerDay)
+	}
+	stddevEffort := p.StddevEffortNew
+	if p.EffortModel == effortExponential {
+		stddevEffort = p.MeanEffortNew
+	}
+	var buf bytes.Buffer
+	frmt := withPrecision("%s mean: %s (requested %.2f) stdev: %s"+
+		" (requested %.2f)\n", places)
+	m, s := meanStdev(counts)
+	buf.WriteString(fmt.Sprintf(frmt, "Tickets per day",
+		orNA("%.2f", m, places), p.MeanNewPerDay, orNA("%.2f", s, places),
+		stddevCount))
+	m, s = meanStdev(efforts)
+	buf.WriteString(fmt.Sprintf(frmt, "Effort per ticket",
+		orNA("%.2f", m, places), p.MeanEffortNew, orNA("%.2f", s, places),
+		stddevEffort))
+	if len(p.EffortQuantize) > 0 {
+		buf.WriteString("Effort distribution:")
+		for _, e := range p.EffortQuantize {
+			n := 0
+			for _, effort := range efforts {
+				if effort == e {
+					n++
+				}
+			}
+			buf.WriteString(fmt.Sprintf(withPrecision(" %dh: %.1f%%",
+				p.Precision), e, percentOf(n, len(efforts))))
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+// printText print the summary of the arrivals and the results of the
+// simulations as readable text
+func printText(p *Params, arrivals [][]*ticket, sumCount, sumEffort int,
+	simset simulationset) {
 	fmt.Println()
-	meanCount := float64(sumCount) / float64(days)
-	fmt.Println("mean ticket count per day:", meanCount)
-	meanEffort := float64(sumEffort) / float64(days)
-	fmt.Println("mean ticket effort per day:", meanEffort)
+	meanCount := float64(sumCount) / float64(p.Days)
+	fmt.Printf(withPrecision("mean ticket count per day: %.2f\n", p.Precision),
+		meanCount)
+	meanEffort := float64(sumEffort) / float64(p.Days)
+	fmt.Printf(withPrecision("mean ticket effort per day: %.2f\n", p.Precision),
+		meanEffort)
+	fmt.Print(samplingReport(p, withoutExpedites(arrivals[:p.Days])))
 	fmt.Println()
 	fmt.Println(simset)
+	if len(simset) < 2 {
+		fmt.Println("Single strategy run, no comparison of strategies")
+		return
+	}
+	if bracket := simset.bracketReport(); bracket != "" {
+		fmt.Println(bracket)
+	}
+	if wipHours := simset.wipHoursReport(); wipHours != "" {
+		fmt.Println(wipHours)
+	}
+	if conwip := simset.conwipReport(); conwip != "" {
+		fmt.Println(conwip)
+	}
+	if mm1 := simset.mm1Report(); mm1 != "" {
+		fmt.Println(mm1)
+	}
+	fmt.Println(simset.summaryTable())
+	fmt.Println(simset.verdict(offeredLoad(p)))
+}
+
+// summaryTable return a table of the compared metrics per strategy, the
+// columns aligned
+func (simset simulationset) summaryTable() string {
+	var buf bytes.Buffer
+	buf.WriteString("Summary\n")
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprint(tw, "# strategy")
+	for _, m := range compareMetrics {
+		fmt.Fprintf(tw, "\t%s", m.name)
+	}
+	fmt.Fprintln(tw)
+	for _, s := range simset {
+		fmt.Fprint(tw, s.id)
+		for _, m := range compareMetrics {
+			v := m.value(s)
+			frmt := withPrecision("%.2f", s.params.Precision)
+			if v == math.Trunc(v) {
+				frmt = "%.0f"
+			}
+			fmt.Fprintf(tw, "\t%s", orNA(frmt, v, s.params.Precision))
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+	return buf.String()
+}
+
+func main() {
+	p, opts := parseArgs()
+	lowest, highest := 0, 0
+	if opts.wipSweep != "" {
+		var err error
+		lowest, highest, err = parseRange(opts.wipSweep)
+		if err != nil {
+			log.Fatal("wip-sweep: ", err)
+		}
+	}
+	// cancel the simulation on interrupt and print the partial results
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	text := opts.format == formatText && !opts.checksum
+	useColor = text && !opts.noColor && isTerminal(os.Stdout)
+	if text {
+		printStability(&p)
+		printSimulatedDataHeader(p.Days, p.Seed)
+	}
+	var rng randSource = rand.New(rand.NewSource(p.Seed))
+	var dump *bufio.Writer
+	if opts.dumpRng != "" {
+		f, err := os.Create(opts.dumpRng)
+		if err != nil {
+			log.Fatal("dump-rng: ", err)
+		}
+		defer f.Close()
+		dump = bufio.NewWriter(f)
+		rng = newLoggingRand(rng, dump)
+	}
+	smp := newSampler(rng, &p)
+	var out io.Writer = io.Discard
+	if text {
+		out = os.Stdout
+	}
+	var arrivals [][]*ticket
+	var sumCount, sumEffort int
+	if opts.tickets != "" {
+		var warnings []string
+		var err error
+		arrivals, sumCount, sumEffort, warnings, err =
+			readTicketsFile(opts.tickets, &p)
+		for _, w := range warnings {
+			log.Print("tickets: ", w)
+		}
+		if err != nil {
+			log.Fatal("tickets: ", err)
+		}
+	} else {
+		arrivals, sumCount, sumEffort = createArrivals(&p, smp, out)
+	}
+	if dump != nil {
+		if err := dump.Flush(); err != nil {
+			log.Fatal("dump-rng: ", err)
+		}
+	}
+	if opts.manifest || opts.manifestFile != "" {
+		m, err := newManifest(&p, arrivals).JSON()
+		if err != nil {
+			log.Fatal("manifest: ", err)
+		}
+		if opts.manifest && text {
+			fmt.Printf("Manifest:\n%s\n", m)
+		}
+		if opts.manifestFile != "" {
+			if err := os.WriteFile(opts.manifestFile, m, 0644); err != nil {
+				log.Fatal("manifest: ", err)
+			}
+		}
+	}
+	simset := NewSimulationset(&p)
+	if text && opts.sample > 0 {
+		simset.sampleTickets(opts.sample)
+	}
+	simset, err := simset.run(ctx, arrivals)
+	if err = warnDrain("Simulation", err); err != nil {
+		log.Print("Simulation stopped after ", simset[0].lastday+1, " days: ", err)
+	}
+	if opts.memstats {
+		fmt.Fprint(os.Stderr, memReport(simset))
+	}
+	if opts.checksum {
+		fmt.Println(resultChecksum(simset))
+		return
+	}
+	printed := simset
+	if opts.sortBy != "" {
+		printed = simset.sortedBy(opts.sortBy)
+	}
+	switch opts.format {
+	case formatInflux:
+		if err := writeInflux(os.Stdout, printed, opts.startTime); err != nil {
+			log.Fatal("influx: ", err)
+		}
+	case formatScatter:
+		if err := writeScatter(os.Stdout, printed); err != nil {
+			log.Fatal("scatter: ", err)
+		}
+	case formatMarkdown:
+		if err := writeMarkdown(os.Stdout, printed, p.Precision); err != nil {
+			log.Fatal("markdown: ", err)
+		}
+	case formatEffort:
+		if err := writeEffortCompletions(os.Stdout, printed); err != nil {
+			log.Fatal("effort: ", err)
+		}
+	default:
+		printText(&p, arrivals, sumCount, sumEffort, printed)
+	}
+	if opts.dumpTickets != "" {
+		if err := dumpTickets(opts.dumpTickets, simset); err != nil {
+			log.Fatal("dump-tickets: ", err)
+		}
+	}
+	if opts.dumpOpen != "" {
+		if rows := simset.snapshotRows(); rows > snapshotWarnRows {
+			log.Printf("dump-open: warning: writing %d rows to %s", rows,
+				opts.dumpOpen)
+		}
+		rows, err := dumpSnapshots(opts.dumpOpen, simset)
+		if err != nil {
+			log.Fatal("dump-open: ", err)
+		}
+		log.Printf("dump-open: wrote %d rows to %s", rows, opts.dumpOpen)
+	}
+	if opts.summary != "" {
+		if err := writeSummary(opts.summary, simset); err != nil {
+			log.Fatal("summary: ", err)
+		}
+	}
+	if text && p.Trace {
+		for _, s := range simset {
+			fmt.Println(s.traceReport())
+		}
+	}
+	if text && opts.efficiency && err == nil {
+		report, err := efficiencyReport(ctx, &p, arrivals, simset)
+		if err != nil {
+			log.Fatal("efficiency: ", err)
+		}
+		fmt.Println(report)
+	}
+	if text && opts.weights != "" {
+		weights, _ := parseWeights(opts.weights)
+		if card := simset.scoreCard(weights); card != "" {
+			fmt.Println(card)
+		}
+	}
+	if text && opts.wipHistogram {
+		fmt.Println(simset.wipHistogram())
+	}
+	if text && opts.sample > 0 {
+		fmt.Print(sampleReport(simset))
+	}
+	if text && opts.wipSweep != "" && err == nil {
+		sweep, err := wipSweep(ctx, &p, arrivals, lowest, highest)
+		if err != nil {
+			log.Fatal("wip-sweep: ", err)
+		}
+		fmt.Println(sweep)
+	}
+	if text && opts.sensitivity > 0 && err == nil {
+		report, err := sensitivity(ctx, &p, arrivals, simset, opts.sensitivity)
+		if err != nil {
+			log.Fatal("sensitivity: ", err)
+		}
+		fmt.Println(report)
+	}
+	if text && opts.ensemble != "" && err == nil {
+		report, err := ensemble(ctx, &p, arrivals, opts.ensemble, opts.ensembleCfg)
+		if err != nil {
+			log.Fatal("ensemble: ", err)
+		}
+		fmt.Println(report)
+	}
+	if text && opts.wipTune != "" && err == nil {
+		lo, hi, err := parseRange(opts.wipTune)
+		if err != nil {
+			log.Fatal("wip-tune: ", err)
+		}
+		wip, leadtime, err := wipTune(ctx, &p, arrivals, lo, hi)
+		if err != nil {
+			log.Fatal("wip-tune: ", err)
+		}
+		fmt.Println(tuneReport(wip, leadtime))
+	}
+	if text && opts.seeds > 0 && err == nil {
+		report, err := tournament(ctx, &p, opts.seeds)
+		if err != nil {
+			log.Fatal("seeds: ", err)
+		}
+		fmt.Println(report)
+	}
+	if text && opts.minCapacity != "" && err == nil {
+		capacity, compliance, met, err := minCapacity(ctx, &p, opts.minCapacity)
+		if err != nil {
+			log.Fatal("min-capacity: ", err)
+		}
+		st, _ := findStrategy(opts.minCapacity)
+		fmt.Println(capacityReport(&p, st.name, capacity, compliance, met))
+	}
+	if text && p.Parallel < 1 && err == nil {
+		report, err := parallelReport(ctx, &p, arrivals, simset)
+		if err != nil {
+			log.Fatal("parallel: ", err)
+		}
+		fmt.Println(report)
+	}
+	if text && p.EffortDiscovery > 0 && err == nil {
+		report, err := discoveryReport(ctx, &p, arrivals, simset)
+		if err != nil {
+			log.Fatal("effort-discovery: ", err)
+		}
+		fmt.Println(report)
+	}
+	if text && p.ExpediteRate > 0 && err == nil {
+		report, err := expediteReport(ctx, &p, arrivals, simset)
+		if err != nil {
+			log.Fatal("expedite: ", err)
+		}
+		fmt.Println(report)
+	}
+	if text && p.Teams > 1 && err == nil {
+		report, err := teamsReport(ctx, &p, arrivals)
+		if err != nil {
+			log.Fatal("teams: ", err)
+		}
+		fmt.Println(report)
+	}
+	if opts.grid != "" && err == nil {
+		axes, _ := parseGrid(opts.grid)
+		count, err := runGrid(ctx, &p, axes, opts.gridFile)
+		if err != nil {
+			log.Fatal("grid: ", err)
+		}
+		if text {
+			fmt.Printf("Grid of %d combinations written to %s\n", count,
+				opts.gridFile)
+		}
+	}
+	if text && opts.compare != "" && err == nil {
+		files := strings.Split(opts.compare, ",")
+		report, err := compare(ctx, &p, files[0], files[1])
+		if err != nil {
+			log.Fatal("compare: ", err)
+		}
+		fmt.Println(report)
+	}
 }
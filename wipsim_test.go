@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"io"
+	"math"
+	"math/big"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestBurndownHours(t *testing.T) {
+	const startday, day = 1, 2
+	tests := []struct {
+		name      string
+		remaining int // remaining work at day
+		hoursleft int
+		hours     int
+		wantLeft  int // hoursleft returned
+		wantNext  int // remaining work the day after
+		wantEnd   bool
+	}{
+		{"zero remaining", 0, 8, 4, 8, 0, false},
+		{"hoursleft zero", 5, 0, 4, 0, 5, true},
+		{"hours exceed remaining", 3, 8, 8, 5, 0, true},
+		{"hours exceed hoursleft", 10, 3, 8, 0, 7, true},
+		{"exact exhaustion", 4, 4, 4, 0, 0, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tk := NewTicket(startday, 10, 10)
+			tk.remaining[day] = tc.remaining
+			left := tk.burndownhours(day, tc.hoursleft, tc.hours, 1)
+			if left != tc.wantLeft {
+				t.Errorf("hoursleft = %d, want %d", left, tc.wantLeft)
+			}
+			if next := tk.remaining[day+1]; next != tc.wantNext {
+				t.Errorf("remaining the day after = %d, want %d", next, tc.wantNext)
+			}
+			wantEnd, wantLead := 0, 0
+			if tc.wantEnd {
+				wantEnd, wantLead = day, day+1-startday
+			}
+			if tk.endday != wantEnd || tk.leadtime != wantLead {
+				t.Errorf("endday, leadtime = %d, %d, want %d, %d", tk.endday,
+					tk.leadtime, wantEnd, wantLead)
+			}
+			worked := tc.hoursleft - tc.wantLeft
+			if worked > 0 && tk.firstwork != day || worked == 0 && tk.firstwork != -1 {
+				t.Errorf("firstwork = %d after %d h worked", tk.firstwork, worked)
+			}
+		})
+	}
+}
+
+// TestVerify over-burn a ticket and check -verify names the ticket and the
+// day, a clean simulation verifies without error
+func TestVerify(t *testing.T) {
+	const day = 2
+	p := NewParams()
+	p.Days = 5
+	st, _ := findStrategy("sjf")
+	sim := NewSimulation(st, &p, p.Days).addTickets([]*ticket{
+		NewTicket(0, 4, p.Days), NewTicket(0, 6, p.Days)})
+	for d := 0; d <= day; d++ {
+		sim.burndownaday(&sim, d)
+	}
+	if err := sim.verify(day); err != nil {
+		t.Errorf("clean simulation: %v", err)
+	}
+	sim.tickets[1].remaining[day+1] = -3
+	err := sim.verify(day)
+	if err == nil {
+		t.Fatal("over-burned ticket not reported")
+	}
+	for _, want := range []string{"ticket 1", "day 2"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not name %q", err, want)
+		}
+	}
+}
+
+// TestBurndownHoursLearning burn a ticket down an hour at a time with a
+// learning factor below the rounding, the fractions must add up
+func TestBurndownHoursLearning(t *testing.T) {
+	const day = 0
+	tk := NewTicket(day, 20, 2)
+	left := 10
+	for left > 0 {
+		left = tk.burndownhours(day, left, 1, 1.1)
+	}
+	if got := tk.current(day); got != 9 {
+		t.Errorf("remaining after 10 h at factor 1.1 = %d, want 9", got)
+	}
+	left = tk.burndownhours(day, 10, 10, 1.1)
+	if got := tk.current(day); got != 0 || left != 1 {
+		t.Errorf("remaining %d, hours left %d, want 0 and 1", got, left)
+	}
+}
+
+// TestStrategiesLeadtimes run every strategy on four tickets and check the
+// leadtime of each ticket against the one computed by hand, 8 h per day
+func TestStrategiesLeadtimes(t *testing.T) {
+	const spec = `# startday, effort, deadline, cost of delay
+0, 10, 3, 1
+0, 4, 2, 8
+1, 2, 1, 1
+1, 6, 4, 3
+`
+	want := map[string][]int{
+		"equal":   {3, 2, 1, 2},
+		"oldest":  {2, 2, 1, 2},
+		"sjf":     {2, 1, 1, 2},
+		"osjf":    {2, 1, 1, 2},
+		"awsjf":   {2, 1, 1, 2},
+		"pull":    {2, 2, 1, 2},
+		"edf":     {2, 1, 1, 2},
+		"swarm":   {2, 2, 1, 2},
+		"kanban":  {2, 1, 1, 2},
+		"cd3":     {3, 1, 1, 1},
+		"small":   {2, 2, 1, 2},
+		"alt":     {2, 1, 1, 2},
+		"cpull":   {5, 2, 1, 3},
+		"hpull":   {3, 2, 1, 2},
+		"maxwait": {2, 1, 1, 2},
+		"conwip":  {3, 1, 1, 2},
+		"wspt":    {2, 1, 1, 2},
+	}
+	p := NewParams()
+	p.Days = 8
+	arrivals, _, _, _, err := readTickets(strings.NewReader(spec), &p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	simset, err := Run(context.Background(), &p, arrivals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(simset) != len(strategies) {
+		t.Fatalf("ran %d strategies, want %d", len(simset), len(strategies))
+	}
+	for _, s := range simset {
+		lts, ok := want[s.id]
+		if !ok {
+			t.Errorf("%s: no leadtimes computed by hand", s.id)
+			continue
+		}
+		for seq, lt := range lts {
+			if got := s.bySeq[seq].leadtime; got != lt {
+				t.Errorf("%s: ticket %d leadtime %d, want %d", s.id, seq, got, lt)
+			}
+		}
+	}
+}
+
+// TestAwsjfFloatWeight run awsjf with the weight of remaining work by days
+// open in floating point and with the former integer division on seeded
+// arrivals, report the days the orders differ and the change of the mean
+// leadtime, the float weight must not be worse
+func TestAwsjfFloatWeight(t *testing.T) {
+	p := NewParams()
+	p.Days = 1000
+	p.Seed = 1
+	arrivals, _, _ := createArrivals(&p,
+		newSampler(rand.New(rand.NewSource(p.Seed)), &p), io.Discard)
+	float := func(t *ticket, day int) float64 {
+		return awsjfWeight(t, day, 1, 1)
+	}
+	integer := func(t *ticket, day int) float64 {
+		return float64(t.current(day) / (day + 1 - t.startday))
+	}
+	type less func(a, b *ticket) bool
+	byWeight := func(weight func(*ticket, int) float64, day int) less {
+		return func(a, b *ticket) bool {
+			return weight(a, day) < weight(b, day)
+		}
+	}
+	differ := 0
+	run := func(weight func(*ticket, int) float64) simulation {
+		st, _ := findStrategy("awsjf")
+		st.burndownaday = func(sim *simulation, day int) {
+			if weight != nil {
+				burndownByWeight(sim, day, func(t *ticket) float64 {
+					return weight(t, day)
+				})
+				return
+			}
+			byFloat := sim.openTickets(day)
+			byInt := append([]*ticket(nil), byFloat...)
+			sim.sortTickets(byFloat, day, byWeight(float, day))
+			sim.sortTickets(byInt, day, byWeight(integer, day))
+			for i := range byFloat {
+				if byFloat[i] != byInt[i] {
+					differ++
+					break
+				}
+			}
+			burndownAwsjf(sim, day)
+		}
+		simset, err := simulationset{NewSimulation(st, &p, p.Days)}.run(
+			context.Background(), arrivals)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return simset[0]
+	}
+	registered, floats, ints := run(nil), run(float), run(integer)
+	for i, tk := range registered.tickets {
+		if tk.leadtime != floats.tickets[i].leadtime {
+			t.Fatalf("awsjf ticket %d leadtime %d, with the float weight %d", i,
+				tk.leadtime, floats.tickets[i].leadtime)
+		}
+	}
+	mf, _, _ := floats.statsLeadTime()
+	mi, _, _ := ints.statsLeadTime()
+	t.Logf("orders differ on %d of %d days, mean leadtime integer %.3f"+
+		" float %.3f, delta %.3f", differ, p.Days, mi, mf, mf-mi)
+	if differ == 0 {
+		t.Error("the integer and the float weight order the tickets the same")
+	}
+	if mf > mi {
+		t.Errorf("mean leadtime with the float weight %.3f > integer %.3f", mf, mi)
+	}
+}
+
+func TestWithPrecision(t *testing.T) {
+	tests := []struct {
+		frmt   string
+		places int
+		want   string
+	}{
+		{"%.2f days", 2, "%.2f days"},
+		{"mean: %.2f stdev: %.2f", 4, "mean: %.4f stdev: %.4f"},
+		{"trend: %+.2f h/day", 3, "trend: %+.3f h/day"},
+		{"efficiency: %.1f%%", 4, "efficiency: %.3f%%"},
+		{"change: %+.1f%%", 0, "change: %+.0f%%"},
+		{"%10.2f %+10.2f", 3, "%10.3f %+10.3f"},
+		{"SLA %.0f%% p%.0f: %d days", 4, "SLA %.0f%% p%.0f: %d days"},
+	}
+	for _, tc := range tests {
+		if got := withPrecision(tc.frmt, tc.places); got != tc.want {
+			t.Errorf("withPrecision(%q, %d) = %q, want %q", tc.frmt, tc.places,
+				got, tc.want)
+		}
+	}
+}
+
+func TestLowestIndex(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		values []float64
+		want   int
+	}{
+		{[]float64{nan, 3, 1, 1}, 2},
+		{[]float64{0.5, nan, 0.7}, 0},
+		{[]float64{nan, nan}, -1},
+		{nil, -1},
+	}
+	for _, tc := range tests {
+		if got := lowestIndex(tc.values); got != tc.want {
+			t.Errorf("lowestIndex(%v) = %d, want %d", tc.values, got, tc.want)
+		}
+	}
+}
+
+// TestWelford compare the running mean and standard deviation of 1e6 values
+// with a large offset against a two-pass reference in 256 bit floats
+func TestWelford(t *testing.T) {
+	const n, offset = 1000000, 1e9
+	rng := rand.New(rand.NewSource(1))
+	values := make([]float64, n)
+	var w welford
+	sum := new(big.Float).SetPrec(256)
+	for i := range values {
+		values[i] = offset + rng.NormFloat64()
+		w.add(values[i])
+		sum.Add(sum, big.NewFloat(values[i]))
+	}
+	mean := new(big.Float).SetPrec(256).Quo(sum, big.NewFloat(n))
+	squares := new(big.Float).SetPrec(256)
+	for _, v := range values {
+		d := new(big.Float).SetPrec(256).Sub(big.NewFloat(v), mean)
+		squares.Add(squares, d.Mul(d, d))
+	}
+	wantMean, _ := mean.Float64()
+	wantVar, _ := squares.Quo(squares, big.NewFloat(n)).Float64()
+	if math.Abs(w.mean()-wantMean)/wantMean > 1e-12 {
+		t.Errorf("mean %.9f, want %.9f", w.mean(), wantMean)
+	}
+	if got := w.stdev() * w.stdev(); math.Abs(got-wantVar)/wantVar > 1e-7 {
+		t.Errorf("variance %.9f, want %.9f", got, wantVar)
+	}
+}